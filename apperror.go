@@ -0,0 +1,194 @@
+package nautilus
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/kataras/iris/v12"
+)
+
+// Context values which are set by SetError and read by RenderError. The
+// message, code and errors values are kept for error handlers which read
+// them directly.
+const (
+	AppErrorKey     = "error"
+	ErrorMessageKey = "message"
+	ErrorCodeKey    = "code"
+	ErrorFieldsKey  = "errors"
+)
+
+// AppError is an error with the data needed to render it as an HTTP
+// response. It could be set in the context by SetError or returned from
+// handlers wrapped by HandleErrors, and is rendered by RenderError.
+type AppError struct {
+	// Status is the HTTP status code of the response
+	Status int `json:"-"`
+
+	// Code is a machine readable code, such as "user_not_found"
+	Code string `json:"code"`
+
+	// Message is a human readable description of the error
+	Message string `json:"message"`
+
+	// Details contains additional data of the error
+	Details map[string]interface{} `json:"details,omitempty"`
+
+	// Fields contains errors of invalid fields, such as validation.Errors
+	Fields interface{} `json:"errors,omitempty"`
+
+	// Type is the URI of the problem type, which is used when the error is
+	// rendered as RFC 7807 problem details
+	Type string `json:"-"`
+
+	// Cause is the underlying error, which is not rendered in responses
+	Cause error `json:"-"`
+}
+
+// Translator translates messages of error responses to the languages
+// requested by clients, such as i18n.Bundle
+type Translator interface {
+	// Translate returns the message with given key in the language of the
+	// request, with placeholders replaced by params
+	Translate(ctx iris.Context, key string, params map[string]interface{}) (string, bool)
+}
+
+// NewAppError returns a new AppError with given status, code and message
+func NewAppError(status int, code string, message string) *AppError {
+	return &AppError{Status: status, Code: code, Message: message}
+}
+
+// StatusError returns a new AppError for given status, which its code
+// and message are derived from the status text, e.g. "not_found" and
+// "Not Found" for 404
+func StatusError(status int) *AppError {
+	text := http.StatusText(status)
+	code := strings.ToLower(strings.ReplaceAll(text, " ", "_"))
+
+	return NewAppError(status, code, text)
+}
+
+// AsAppError returns err as an AppError if it is (or wraps) one. Other
+// errors are returned as an internal server error, which its cause is err
+// and its message does not expose the details of err.
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	return StatusError(http.StatusInternalServerError).WithCause(err)
+}
+
+// Error implements error interface
+func (e *AppError) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+
+	return e.Message
+}
+
+// Unwrap returns the cause of the error
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// WithCause returns a copy of the error with given cause
+func (e *AppError) WithCause(cause error) *AppError {
+	c := *e
+	c.Cause = cause
+
+	return &c
+}
+
+// WithDetails returns a copy of the error with given details, which are
+// merged with the existing details
+func (e *AppError) WithDetails(details map[string]interface{}) *AppError {
+	c := *e
+	c.Details = make(map[string]interface{}, len(e.Details)+len(details))
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+
+	for k, v := range details {
+		c.Details[k] = v
+	}
+
+	return &c
+}
+
+// WithFields returns a copy of the error with given field errors
+func (e *AppError) WithFields(fields interface{}) *AppError {
+	c := *e
+	c.Fields = fields
+
+	return &c
+}
+
+// SetError sets given error (converted by AsAppError) in the context,
+// sets the response status and stops the execution of next handlers, so
+// the error handlers of the application render it.
+//
+//	if err := service.Save(user); err != nil {
+//		nautilus.SetError(ctx, err)
+//		return
+//	}
+func SetError(ctx iris.Context, err error) {
+	appErr := AsAppError(err)
+
+	ctx.Values().Set(AppErrorKey, appErr)
+	ctx.Values().Set(ErrorMessageKey, appErr.Message)
+	ctx.Values().Set(ErrorCodeKey, appErr.Code)
+	if appErr.Fields != nil {
+		ctx.Values().Set(ErrorFieldsKey, appErr.Fields)
+	}
+
+	status := appErr.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	ctx.StatusCode(status)
+	ctx.StopExecution()
+}
+
+// HandleErrors converts a handler which returns an error to iris.Handler.
+// Returned errors are set in the context by SetError.
+func HandleErrors(handler func(ctx iris.Context) error) iris.Handler {
+	return func(ctx iris.Context) {
+		if err := handler(ctx); err != nil {
+			SetError(ctx, err)
+		}
+	}
+}
+
+// GetError returns the error of the context. If no AppError is set in the
+// context, it is built from the response status and the message, code and
+// errors values of the context, so errors fired by the router (e.g. 404)
+// are described too.
+func GetError(ctx iris.Context) *AppError {
+	if appErr, ok := ctx.Values().Get(AppErrorKey).(*AppError); ok {
+		return appErr
+	}
+
+	appErr := StatusError(ctx.GetStatusCode())
+	if message := ctx.Values().GetString(ErrorMessageKey); message != "" {
+		appErr.Message = message
+	}
+
+	if code := ctx.Values().GetString(ErrorCodeKey); code != "" {
+		appErr.Code = code
+	}
+
+	appErr.Fields = ctx.Values().Get(ErrorFieldsKey)
+
+	return appErr
+}
+
+// RenderError writes the error of the context (see GetError) as a JSON
+// response. It is the default error handler of bootstrap applications and
+// NewErrorRenderer could be used for other formats.
+func RenderError(ctx iris.Context) {
+	_, _ = ctx.JSON(GetError(ctx))
+}
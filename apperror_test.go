@@ -0,0 +1,93 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppError(t *testing.T) {
+	cause := errors.New("no rows")
+	err := NewAppError(http.StatusNotFound, "user_not_found", "user not found")
+
+	assert.Equal(t, "user not found", err.Error())
+	assert.Equal(t, "user not found: no rows", err.WithCause(cause).Error())
+	assert.True(t, errors.Is(err.WithCause(cause), cause))
+	assert.Nil(t, err.Cause)
+
+	detailed := err.WithDetails(map[string]interface{}{"id": 1}).WithDetails(map[string]interface{}{"name": "john"})
+	assert.Equal(t, map[string]interface{}{"id": 1, "name": "john"}, detailed.Details)
+	assert.Nil(t, err.Details)
+
+	assert.Equal(t, &AppError{Status: 405, Code: "method_not_allowed", Message: "Method Not Allowed"}, StatusError(405))
+
+	wrapped := fmt.Errorf("loading user: %w", err)
+	assert.Equal(t, err, AsAppError(wrapped))
+
+	internal := AsAppError(cause)
+	assert.Equal(t, http.StatusInternalServerError, internal.Status)
+	assert.Equal(t, "internal_server_error", internal.Code)
+	assert.Equal(t, "Internal Server Error", internal.Message)
+	assert.Equal(t, cause, internal.Cause)
+}
+
+func TestRenderError(t *testing.T) {
+	app := iris.New()
+	app.OnAnyErrorCode(RenderError)
+
+	app.Get("/users", HandleErrors(func(ctx iris.Context) error {
+		return NewAppError(http.StatusNotFound, "user_not_found", "user not found").
+			WithDetails(map[string]interface{}{"id": "1"})
+	}))
+	app.Get("/fields", func(ctx iris.Context) {
+		SetError(ctx, StatusError(http.StatusBadRequest).WithFields(map[string][]string{"name": {"is required"}}))
+	})
+	app.Get("/internal", HandleErrors(func(ctx iris.Context) error {
+		return errors.New("connection refused")
+	}))
+	app.Get("/legacy", func(ctx iris.Context) {
+		ctx.Values().Set("message", "custom message")
+		ctx.StatusCode(http.StatusForbidden)
+	})
+	app.Get("/ok", HandleErrors(func(ctx iris.Context) error {
+		_, _ = ctx.WriteString("ok")
+		return nil
+	}))
+
+	assert.Nil(t, app.Build())
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return recorder
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users", 404, `{"code":"user_not_found","message":"user not found","details":{"id":"1"}}`},
+		{"/fields", 400, `{"code":"bad_request","message":"Bad Request","errors":{"name":["is required"]}}`},
+		{"/internal", 500, `{"code":"internal_server_error","message":"Internal Server Error"}`},
+		{"/legacy", 403, `{"code":"forbidden","message":"custom message"}`},
+		{"/missing", 404, `{"code":"not_found","message":"Not Found"}`},
+	}
+
+	for _, test := range tests {
+		response := serve(test.path)
+
+		assert.Equal(t, test.status, response.Code, test.path)
+		assert.JSONEq(t, test.body, response.Body.String(), test.path)
+	}
+
+	response := serve("/ok")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "ok", response.Body.String())
+}
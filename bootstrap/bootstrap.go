@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"time"
 
+	"github.com/Kamva/nautilus"
 	"github.com/Kamva/shark/middleware"
 
 	"github.com/kataras/iris/v12"
@@ -18,19 +19,36 @@ type Configurator func(*Application)
 type Application struct {
 	*iris.Application
 	AppSpawnDate time.Time
+
+	// BuildInfo is the build information reported by health endpoints
+	BuildInfo BuildInfo
+
+	// ShutdownTimeout is the time which in-flight requests are given to
+	// complete on shutdown, which defaults to DefaultShutdownTimeout
+	ShutdownTimeout time.Duration
+
+	// ShutdownDelay is the time which the application keeps serving
+	// requests after readiness starts failing on shutdown, so load
+	// balancers could stop sending new requests before the server stops
+	ShutdownDelay time.Duration
+
+	lifecycle *lifecycle
+	health    *health
 }
 
 // SetupErrorHandlers will handle response for any kind of errors
-// It uses `message` and `code` value that has set in context
-func (a *Application) SetupErrorHandlers() {
-	a.OnAnyErrorCode(func(context iris.Context) {
-		_, _ = context.JSON(iris.Map{
-			"message": context.Values().GetString("message"),
-			"code":    context.Values().GetString("code"),
-		})
-
-		return
-	})
+// It renders the nautilus.AppError that has set in context (e.g. by
+// nautilus.SetError or validation.HandleError) as JSON. Errors fired by
+// iris itself, such as 404 and 405, are rendered with a code and message
+// derived from their status, unless `message` and `code` values are set.
+//
+// Errors are rendered as `{message, code}` documents by default. Options
+// could render them as RFC 7807 problem details (application/problem+json)
+// or choose the format based on the Accept header of requests, and
+// localize messages by a translator such as i18n.Bundle, which resolves
+// the code of errors from its catalogs.
+func (a *Application) SetupErrorHandlers(opts ...nautilus.ErrorRendererOptions) {
+	a.OnAnyErrorCode(nautilus.NewErrorRenderer(opts...))
 }
 
 // Configure runs all given configurators in a pipeline
@@ -51,15 +69,13 @@ func (a *Application) Bootstrap() *Application {
 	return a
 }
 
-// Listen will run the application on given address
-func (a *Application) Listen(address string, configurators ...iris.Configurator) {
-	_ = a.Run(iris.Addr(address), configurators...)
-}
-
 // New will return a new instance of Application
 func New() *Application {
 	return &Application{
-		AppSpawnDate: time.Now(),
-		Application:  iris.New(),
+		AppSpawnDate:    time.Now(),
+		Application:     iris.New(),
+		ShutdownTimeout: DefaultShutdownTimeout,
+		lifecycle:       newLifecycle(),
+		health:          &health{},
 	}
 }
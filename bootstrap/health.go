@@ -0,0 +1,346 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12"
+)
+
+// DefaultHealthCheckTimeout is the default timeout of health checks
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// Health statuses of checks and reports
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusDegraded     = "degraded"
+	HealthStatusFail         = "fail"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+// HealthCheck checks a dependency of the application, such as a database
+// connection, and returns an error if it is not healthy
+type HealthCheck func(ctx context.Context) error
+
+// HealthCheckOptions contains options of a health check
+type HealthCheckOptions struct {
+	// Timeout is the maximum duration of the check, which defaults to
+	// DefaultHealthCheckTimeout
+	Timeout time.Duration
+
+	// NonCritical checks are reported, but their failure does not fail
+	// the readiness of the application and results in degraded status
+	NonCritical bool
+
+	// CacheDuration is the duration which the result of the check is
+	// reused, to avoid checking dependencies on every probe
+	CacheDuration time.Duration
+
+	// Liveness includes the check in the liveness report too. Checks are
+	// only included in the readiness report by default, as a failing
+	// dependency should not restart the application.
+	Liveness bool
+}
+
+// HealthOptions contains options of health endpoints
+type HealthOptions struct {
+	// LivenessPath defaults to "/healthz"
+	LivenessPath string
+
+	// ReadinessPath defaults to "/readyz"
+	ReadinessPath string
+}
+
+// BuildInfo contains build information of the application, which is
+// usually set by linker flags
+type BuildInfo struct {
+	Version   string `json:"version,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// HealthCheckResult is the result of a health check
+type HealthCheckResult struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Critical  bool      `json:"critical"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached,omitempty"`
+}
+
+// HealthReport is the response of health endpoints
+type HealthReport struct {
+	Status        string                       `json:"status"`
+	StartedAt     time.Time                    `json:"started_at"`
+	Uptime        string                       `json:"uptime"`
+	UptimeSeconds int64                        `json:"uptime_seconds"`
+	Build         BuildInfo                    `json:"build"`
+	Checks        map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+type healthCheck struct {
+	name    string
+	check   HealthCheck
+	options HealthCheckOptions
+
+	mutex  sync.Mutex
+	result HealthCheckResult
+
+	// running is closed when the run which is in flight returns and
+	// started is the start time of that run
+	running chan struct{}
+	started time.Time
+}
+
+// health keeps the health checks of an application
+type health struct {
+	mutex  sync.RWMutex
+	checks []*healthCheck
+}
+
+// AddHealthCheck registers a health check with given name, which is
+// reported by health endpoints. Names are the keys of checks in reports,
+// so it panics if a check with the same name is already registered.
+func (a *Application) AddHealthCheck(name string, check HealthCheck, opts ...HealthCheckOptions) {
+	var o HealthCheckOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultHealthCheckTimeout
+	}
+
+	a.health.mutex.Lock()
+	defer a.health.mutex.Unlock()
+
+	for _, existing := range a.health.checks {
+		if existing.name == name {
+			panic(fmt.Sprintf("bootstrap: health check %q is already registered", name))
+		}
+	}
+
+	a.health.checks = append(a.health.checks, &healthCheck{name: name, check: check, options: o})
+}
+
+// Liveness reports whether the application is alive, running the checks
+// which are included in liveness
+func (a *Application) Liveness(ctx context.Context) HealthReport {
+	return a.healthReport(ctx, true)
+}
+
+// Readiness reports whether the application is ready to serve requests,
+// running all health checks. It fails during shutdown without running the
+// checks, so load balancers stop sending new requests.
+func (a *Application) Readiness(ctx context.Context) HealthReport {
+	if a.ShuttingDown() {
+		report := a.newHealthReport()
+		report.Status = HealthStatusShuttingDown
+
+		return report
+	}
+
+	return a.healthReport(ctx, false)
+}
+
+// SetupHealthEndpoints registers liveness and readiness endpoints, which
+// respond the health report as JSON, with 503 status if it is failing
+func (a *Application) SetupHealthEndpoints(opts ...HealthOptions) {
+	var o HealthOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.LivenessPath == "" {
+		o.LivenessPath = "/healthz"
+	}
+
+	if o.ReadinessPath == "" {
+		o.ReadinessPath = "/readyz"
+	}
+
+	a.Get(o.LivenessPath, healthHandler(a.Liveness))
+	a.Get(o.ReadinessPath, healthHandler(a.Readiness))
+}
+
+func healthHandler(report func(ctx context.Context) HealthReport) iris.Handler {
+	return func(ctx iris.Context) {
+		result := report(ctx.Request().Context())
+
+		ctx.Header("Cache-Control", "no-store")
+		if result.Status == HealthStatusFail || result.Status == HealthStatusShuttingDown {
+			ctx.StatusCode(iris.StatusServiceUnavailable)
+		}
+
+		_, _ = ctx.JSON(result)
+	}
+}
+
+func (a *Application) newHealthReport() HealthReport {
+	uptime := time.Since(a.AppSpawnDate)
+
+	build := a.BuildInfo
+	build.GoVersion = runtime.Version()
+
+	return HealthReport{
+		Status:        HealthStatusOK,
+		StartedAt:     a.AppSpawnDate,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime / time.Second),
+		Build:         build,
+	}
+}
+
+// healthReport runs the health checks concurrently and reports their
+// results. Only checks included in liveness are run if liveness is true.
+func (a *Application) healthReport(ctx context.Context, liveness bool) HealthReport {
+	report := a.newHealthReport()
+
+	a.health.mutex.RLock()
+	var checks []*healthCheck
+	for _, check := range a.health.checks {
+		if !liveness || check.options.Liveness {
+			checks = append(checks, check)
+		}
+	}
+	a.health.mutex.RUnlock()
+
+	if len(checks) == 0 {
+		return report
+	}
+
+	results := make([]HealthCheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *healthCheck) {
+			defer wg.Done()
+			results[i] = check.run(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report.Checks = make(map[string]HealthCheckResult, len(checks))
+	for i, result := range results {
+		report.Checks[checks[i].name] = result
+
+		switch {
+		case result.Status == HealthStatusOK:
+		case result.Critical:
+			report.Status = HealthStatusFail
+		case report.Status == HealthStatusOK:
+			report.Status = HealthStatusDegraded
+		}
+	}
+
+	return report
+}
+
+// run returns the cached result of the check if it is not expired, or
+// runs the check. Only one run of a check is in flight at a time, so
+// probes wait for the run which is in flight, if any, and a check which
+// does not return in its timeout is reported as timed out until it returns.
+func (c *healthCheck) run(ctx context.Context) HealthCheckResult {
+	c.mutex.Lock()
+
+	if !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < c.options.CacheDuration {
+		result := c.result
+		result.Cached = true
+		c.mutex.Unlock()
+
+		return result
+	}
+
+	if c.running == nil {
+		c.start(ctx)
+	}
+
+	running, started := c.running, c.started
+	c.mutex.Unlock()
+
+	timer := time.NewTimer(time.Until(started.Add(c.options.Timeout)))
+	defer timer.Stop()
+
+	select {
+	case <-running:
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		return c.result
+	case <-timer.C:
+		return c.newResult(started, fmt.Errorf("timed out after %s", c.options.Timeout))
+	}
+}
+
+// start runs the check in a new goroutine, which records its result when
+// it returns. Checks are not canceled with ctx, which is the context of
+// the probe request, so a disconnected client does not fail the check and
+// its cached result. It must be called with the mutex of the check held.
+func (c *healthCheck) start(ctx context.Context) {
+	running := make(chan struct{})
+	started := time.Now()
+	c.running, c.started = running, started
+
+	go func() {
+		checkCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, c.options.Timeout)
+		defer cancel()
+
+		err := c.check(checkCtx)
+		if err != nil && checkCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", c.options.Timeout)
+		}
+
+		c.mutex.Lock()
+		c.result = c.newResult(started, err)
+		c.running = nil
+		c.mutex.Unlock()
+
+		close(running)
+	}()
+}
+
+// newResult returns the result of a run of the check which is started at
+// given time
+func (c *healthCheck) newResult(started time.Time, err error) HealthCheckResult {
+	result := HealthCheckResult{
+		Status:    HealthStatusOK,
+		Critical:  !c.options.NonCritical,
+		Duration:  time.Since(started).Round(time.Millisecond).String(),
+		CheckedAt: started,
+	}
+
+	if err != nil {
+		result.Status = HealthStatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// detachedContext keeps values of its parent without its deadline and
+// cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
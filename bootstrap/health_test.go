@@ -0,0 +1,220 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func healthy(ctx context.Context) error {
+	return nil
+}
+
+func failing(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestApplication_Readiness(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		app := New()
+		app.AddHealthCheck("database", healthy)
+		app.AddHealthCheck("cache", healthy, HealthCheckOptions{NonCritical: true})
+
+		report := app.Readiness(context.Background())
+		assert.Equal(t, HealthStatusOK, report.Status)
+		assert.Len(t, report.Checks, 2)
+		assert.True(t, report.Checks["database"].Critical)
+		assert.False(t, report.Checks["cache"].Critical)
+	})
+	t.Run("degraded", func(t *testing.T) {
+		app := New()
+		app.AddHealthCheck("database", healthy)
+		app.AddHealthCheck("cache", failing, HealthCheckOptions{NonCritical: true})
+
+		report := app.Readiness(context.Background())
+		assert.Equal(t, HealthStatusDegraded, report.Status)
+		assert.Equal(t, HealthStatusFail, report.Checks["cache"].Status)
+		assert.Equal(t, "connection refused", report.Checks["cache"].Error)
+	})
+	t.Run("fail", func(t *testing.T) {
+		app := New()
+		app.AddHealthCheck("database", failing)
+		app.AddHealthCheck("cache", failing, HealthCheckOptions{NonCritical: true})
+
+		report := app.Readiness(context.Background())
+		assert.Equal(t, HealthStatusFail, report.Status)
+		assert.Equal(t, HealthStatusFail, report.Checks["database"].Status)
+	})
+	t.Run("timeout", func(t *testing.T) {
+		app := New()
+
+		release := make(chan struct{})
+		defer close(release)
+
+		app.AddHealthCheck("stuck", func(ctx context.Context) error {
+			<-release
+			return nil
+		}, HealthCheckOptions{Timeout: 10 * time.Millisecond})
+
+		report := app.Readiness(context.Background())
+		assert.Equal(t, HealthStatusFail, report.Status)
+		assert.Equal(t, "timed out after 10ms", report.Checks["stuck"].Error)
+	})
+	t.Run("hung check", func(t *testing.T) {
+		app := New()
+
+		var calls int32
+		release := make(chan struct{})
+		app.AddHealthCheck("stuck", func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return nil
+		}, HealthCheckOptions{Timeout: 10 * time.Millisecond})
+
+		// Probes do not start another run while the hung one is in flight
+		for i := 0; i < 3; i++ {
+			report := app.Readiness(context.Background())
+			assert.Equal(t, HealthStatusFail, report.Status)
+			assert.Equal(t, "timed out after 10ms", report.Checks["stuck"].Error)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		// Probes are reported as timed out until the released run returns
+		close(release)
+
+		var report HealthReport
+		for i := 0; i < 100 && report.Status != HealthStatusOK; i++ {
+			time.Sleep(time.Millisecond)
+			report = app.Readiness(context.Background())
+		}
+
+		assert.Equal(t, HealthStatusOK, report.Status)
+		assert.True(t, atomic.LoadInt32(&calls) <= 2)
+	})
+	t.Run("canceled probe", func(t *testing.T) {
+		app := New()
+		app.AddHealthCheck("database", func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+				return nil
+			}
+		}, HealthCheckOptions{CacheDuration: time.Minute})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report := app.Readiness(ctx)
+		assert.Equal(t, HealthStatusOK, report.Status)
+
+		report = app.Readiness(context.Background())
+		assert.Equal(t, HealthStatusOK, report.Status)
+		assert.True(t, report.Checks["database"].Cached)
+	})
+	t.Run("shutting down", func(t *testing.T) {
+		app := New()
+
+		var calls int32
+		app.AddHealthCheck("database", func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		})
+
+		assert.Nil(t, app.Shutdown(context.Background()))
+
+		report := app.Readiness(context.Background())
+		assert.Equal(t, HealthStatusShuttingDown, report.Status)
+		assert.Empty(t, report.Checks)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestApplication_Liveness(t *testing.T) {
+	app := New()
+	app.AddHealthCheck("database", failing)
+	app.AddHealthCheck("deadlock", healthy, HealthCheckOptions{Liveness: true})
+
+	report := app.Liveness(context.Background())
+	assert.Equal(t, HealthStatusOK, report.Status)
+	assert.Len(t, report.Checks, 1)
+	assert.Contains(t, report.Checks, "deadlock")
+
+	report = app.Readiness(context.Background())
+	assert.Equal(t, HealthStatusFail, report.Status)
+	assert.Len(t, report.Checks, 2)
+}
+
+func TestApplication_HealthCheckCache(t *testing.T) {
+	app := New()
+
+	var calls int32
+	app.AddHealthCheck("database", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, HealthCheckOptions{CacheDuration: 50 * time.Millisecond})
+
+	report := app.Readiness(context.Background())
+	assert.False(t, report.Checks["database"].Cached)
+
+	report = app.Readiness(context.Background())
+	assert.True(t, report.Checks["database"].Cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	time.Sleep(60 * time.Millisecond)
+
+	report = app.Readiness(context.Background())
+	assert.False(t, report.Checks["database"].Cached)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestApplication_AddHealthCheck(t *testing.T) {
+	app := New()
+	app.AddHealthCheck("database", healthy)
+
+	assert.PanicsWithValue(t, `bootstrap: health check "database" is already registered`, func() {
+		app.AddHealthCheck("database", failing)
+	})
+}
+
+func TestApplication_SetupHealthEndpoints(t *testing.T) {
+	app := New()
+	app.BuildInfo = BuildInfo{Version: "1.0.0"}
+	app.AddHealthCheck("database", failing)
+	app.SetupHealthEndpoints()
+	assert.Nil(t, app.Build())
+
+	serve := func(path string) (*httptest.ResponseRecorder, HealthReport) {
+		recorder := httptest.NewRecorder()
+		app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var report HealthReport
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+
+		return recorder, report
+	}
+
+	response, report := serve("/healthz")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "no-store", response.Header().Get("Cache-Control"))
+	assert.Equal(t, HealthStatusOK, report.Status)
+	assert.Equal(t, "1.0.0", report.Build.Version)
+	assert.NotEmpty(t, report.Build.GoVersion)
+
+	response, report = serve("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Equal(t, HealthStatusFail, report.Status)
+
+	assert.Nil(t, app.Shutdown(context.Background()))
+
+	response, report = serve("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Equal(t, HealthStatusShuttingDown, report.Status)
+}
@@ -0,0 +1,281 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kataras/iris/v12"
+)
+
+// DefaultShutdownTimeout is the default time which in-flight requests are
+// given to complete on shutdown
+const DefaultShutdownTimeout = 30 * time.Second
+
+// DefaultHookTimeout is the default timeout of start and shutdown hooks
+const DefaultHookTimeout = 10 * time.Second
+
+// exit terminates the process when a second signal is received during
+// shutdown, which is replaced in tests
+var exit = os.Exit
+
+// Hook is a function which is called on start or shutdown of the
+// application, such as connecting to or closing a database pool. The
+// context is canceled when the timeout of the hook is exceeded.
+type Hook func(ctx context.Context) error
+
+// HookOptions contains options of a start or shutdown hook
+type HookOptions struct {
+	// Timeout is the maximum duration of the hook, which defaults to
+	// DefaultHookTimeout
+	Timeout time.Duration
+}
+
+type namedHook struct {
+	name    string
+	hook    Hook
+	timeout time.Duration
+}
+
+// lifecycle keeps hooks and the shutdown state of an application
+type lifecycle struct {
+	mutex         sync.Mutex
+	startHooks    []namedHook
+	shutdownHooks []namedHook
+
+	// notStarted contains names of the start hooks which did not complete
+	// when the start failed, whose shutdown hooks are skipped
+	notStarted map[string]bool
+
+	shutdownOnce sync.Once
+	shuttingDown chan struct{}
+	stopped      chan struct{}
+	shutdownErr  error
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		shuttingDown: make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+}
+
+// OnStart registers a hook which is called by Listen before the server
+// starts. Hooks are called in the order they are registered and the first
+// failing hook stops the start, in which case the application is shut down
+// to release resources of the hooks which are already completed.
+func (a *Application) OnStart(name string, hook Hook, opts ...HookOptions) {
+	a.lifecycle.mutex.Lock()
+	defer a.lifecycle.mutex.Unlock()
+
+	a.lifecycle.startHooks = append(a.lifecycle.startHooks, newNamedHook(name, hook, opts))
+}
+
+// OnShutdown registers a hook which is called on shutdown, after in-flight
+// requests are completed. Hooks are called in the order they are registered
+// and all of them are called even if some of them fail. A shutdown hook is
+// paired with the start hook of the same name, so it is skipped if the
+// start fails before that start hook completes.
+func (a *Application) OnShutdown(name string, hook Hook, opts ...HookOptions) {
+	a.lifecycle.mutex.Lock()
+	defer a.lifecycle.mutex.Unlock()
+
+	a.lifecycle.shutdownHooks = append(a.lifecycle.shutdownHooks, newNamedHook(name, hook, opts))
+}
+
+func newNamedHook(name string, hook Hook, opts []HookOptions) namedHook {
+	timeout := DefaultHookTimeout
+	if len(opts) > 0 && opts[0].Timeout > 0 {
+		timeout = opts[0].Timeout
+	}
+
+	return namedHook{name: name, hook: hook, timeout: timeout}
+}
+
+// Listen will run the application on given address. Start hooks are
+// called before the server starts and on SIGINT or SIGTERM the application
+// is shut down gracefully (see Shutdown). Another signal during the
+// shutdown exits the process immediately. It blocks until the application
+// is shut down and returns the errors of starting, serving or shutting
+// down the application.
+func (a *Application) Listen(address string, configurators ...iris.Configurator) error {
+	if err := a.start(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go a.handleSignals(signals)
+
+	configurators = append(configurators, iris.WithoutInterruptHandler, iris.WithoutServerError(iris.ErrServerClosed))
+	if err := a.Run(iris.Addr(address), configurators...); err != nil && !errors.Is(err, iris.ErrServerClosed) {
+		// Resources of start hooks are released if the server fails
+		if shutdownErr := a.Shutdown(context.Background()); shutdownErr != nil {
+			return fmt.Errorf("%v; %v", err, shutdownErr)
+		}
+
+		return err
+	}
+
+	// The server may be closed without calling Shutdown, so it is called
+	// to wait for the shutdown or to run shutdown hooks
+	return a.Shutdown(context.Background())
+}
+
+// start calls start hooks in order and shuts down the application if any
+// of them fails
+func (a *Application) start() error {
+	a.lifecycle.mutex.Lock()
+	startHooks := a.lifecycle.startHooks
+	a.lifecycle.mutex.Unlock()
+
+	for i, hook := range startHooks {
+		err := runHook(context.Background(), hook)
+		if err == nil {
+			continue
+		}
+
+		// Components of the failed hook and the next ones are not started,
+		// so they should not be shut down
+		notStarted := make(map[string]bool)
+		for _, next := range startHooks[i:] {
+			notStarted[next.name] = true
+		}
+
+		for _, completed := range startHooks[:i] {
+			delete(notStarted, completed.name)
+		}
+
+		a.lifecycle.mutex.Lock()
+		a.lifecycle.notStarted = notStarted
+		a.lifecycle.mutex.Unlock()
+
+		err = fmt.Errorf("start hook %s: %v", hook.name, err)
+		if shutdownErr := a.Shutdown(context.Background()); shutdownErr != nil {
+			return fmt.Errorf("%v; %v", err, shutdownErr)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// handleSignals shuts down the application on the first received signal
+// and exits the process on the next one, if the shutdown is not finished
+// yet. It returns when the application is stopped.
+func (a *Application) handleSignals(signals <-chan os.Signal) {
+	select {
+	case sig := <-signals:
+		a.Logger().Infof("received %s signal, shutting down", sig)
+		go func() {
+			_ = a.Shutdown(context.Background())
+		}()
+	case <-a.lifecycle.shuttingDown:
+	}
+
+	select {
+	case sig := <-signals:
+		a.Logger().Warnf("received %s signal during shutdown, exiting", sig)
+		exit(1)
+	case <-a.lifecycle.stopped:
+	}
+}
+
+// Shutdown gracefully shuts down the application. Readiness fails from
+// the start of the shutdown and after ShutdownDelay, it stops accepting
+// new requests, waits for in-flight requests to complete up to
+// ShutdownTimeout and then calls shutdown hooks. Calling it more than
+// once waits for the first shutdown and returns its result.
+func (a *Application) Shutdown(ctx context.Context) error {
+	a.lifecycle.shutdownOnce.Do(func() {
+		close(a.lifecycle.shuttingDown)
+
+		if a.ShutdownDelay > 0 {
+			select {
+			case <-time.After(a.ShutdownDelay):
+			case <-ctx.Done():
+			}
+		}
+
+		var messages []string
+
+		timeout := a.ShutdownTimeout
+		if timeout <= 0 {
+			timeout = DefaultShutdownTimeout
+		}
+
+		drainCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := a.Application.Shutdown(drainCtx); err != nil {
+			messages = append(messages, fmt.Sprintf("server shutdown: %v", err))
+		}
+		cancel()
+
+		a.lifecycle.mutex.Lock()
+		shutdownHooks := a.lifecycle.shutdownHooks
+		notStarted := a.lifecycle.notStarted
+		a.lifecycle.mutex.Unlock()
+
+		for _, hook := range shutdownHooks {
+			if notStarted[hook.name] {
+				continue
+			}
+
+			if err := runHook(ctx, hook); err != nil {
+				messages = append(messages, fmt.Sprintf("shutdown hook %s: %v", hook.name, err))
+			}
+		}
+
+		if len(messages) > 0 {
+			a.lifecycle.shutdownErr = errors.New(strings.Join(messages, "; "))
+		}
+
+		close(a.lifecycle.stopped)
+	})
+
+	<-a.lifecycle.stopped
+	return a.lifecycle.shutdownErr
+}
+
+// ShuttingDown checks whether the shutdown of the application is started
+func (a *Application) ShuttingDown() bool {
+	select {
+	case <-a.lifecycle.shuttingDown:
+		return true
+	default:
+		return false
+	}
+}
+
+// runHook calls given hook with its timeout. Hooks which do not return
+// after their timeout are abandoned, so a stuck hook does not block the
+// start or shutdown. If ctx is done before the timeout, its error is
+// returned.
+func runHook(ctx context.Context, hook namedHook) error {
+	hookCtx, cancel := context.WithTimeout(ctx, hook.timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- hook.hook(hookCtx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-hookCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("timed out after %s", hook.timeout)
+	}
+}
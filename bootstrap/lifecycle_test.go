@@ -0,0 +1,192 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records names of called hooks
+type recorder struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (r *recorder) hook(name string, err error) Hook {
+	return func(ctx context.Context) error {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		r.calls = append(r.calls, name)
+		return err
+	}
+}
+
+func (r *recorder) names() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string(nil), r.calls...)
+}
+
+func TestApplication_StartFailure(t *testing.T) {
+	app := New()
+	r := &recorder{}
+
+	app.OnStart("first", r.hook("start first", nil))
+	app.OnStart("second", r.hook("start second", errors.New("boom")))
+	app.OnStart("third", r.hook("start third", nil))
+	app.OnShutdown("first", r.hook("shutdown first", nil))
+	app.OnShutdown("second", r.hook("shutdown second", nil))
+	app.OnShutdown("third", r.hook("shutdown third", nil))
+	app.OnShutdown("logger", r.hook("shutdown logger", nil))
+
+	// The server is not started, since a start hook fails, and only the
+	// components which are started and the unpaired hooks are shut down
+	err := app.Listen("127.0.0.1:0")
+	assert.EqualError(t, err, "start hook second: boom")
+
+	assert.Equal(t, []string{"start first", "start second", "shutdown first", "shutdown logger"}, r.names())
+	assert.True(t, app.ShuttingDown())
+}
+
+func TestApplication_Shutdown(t *testing.T) {
+	t.Run("all hooks are called", func(t *testing.T) {
+		app := New()
+		r := &recorder{}
+
+		app.OnShutdown("first", r.hook("first", nil))
+		app.OnShutdown("second", r.hook("second", errors.New("boom")))
+		app.OnShutdown("third", r.hook("third", errors.New("failed")))
+		app.OnShutdown("fourth", r.hook("fourth", nil))
+
+		assert.False(t, app.ShuttingDown())
+
+		err := app.Shutdown(context.Background())
+		assert.EqualError(t, err, "shutdown hook second: boom; shutdown hook third: failed")
+		assert.Equal(t, []string{"first", "second", "third", "fourth"}, r.names())
+		assert.True(t, app.ShuttingDown())
+	})
+	t.Run("hook timeout", func(t *testing.T) {
+		app := New()
+		r := &recorder{}
+
+		release := make(chan struct{})
+		defer close(release)
+
+		app.OnShutdown("stuck", func(ctx context.Context) error {
+			<-release
+			return nil
+		}, HookOptions{Timeout: 10 * time.Millisecond})
+		app.OnShutdown("next", r.hook("next", nil))
+
+		err := app.Shutdown(context.Background())
+		assert.EqualError(t, err, "shutdown hook stuck: timed out after 10ms")
+		assert.Equal(t, []string{"next"}, r.names())
+	})
+	t.Run("canceled context", func(t *testing.T) {
+		app := New()
+
+		release := make(chan struct{})
+		defer close(release)
+
+		app.OnShutdown("stuck", func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := app.Shutdown(ctx)
+		assert.EqualError(t, err, "shutdown hook stuck: context canceled")
+	})
+	t.Run("repeated", func(t *testing.T) {
+		app := New()
+		r := &recorder{}
+
+		app.OnShutdown("once", r.hook("once", errors.New("boom")))
+
+		var wg sync.WaitGroup
+		errs := make([]error, 3)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = app.Shutdown(context.Background())
+			}(i)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			assert.EqualError(t, err, "shutdown hook once: boom")
+		}
+
+		assert.EqualError(t, app.Shutdown(context.Background()), "shutdown hook once: boom")
+		assert.Equal(t, []string{"once"}, r.names())
+	})
+}
+
+func TestApplication_HandleSignals(t *testing.T) {
+	codes := make(chan int, 1)
+	exit = func(code int) {
+		codes <- code
+	}
+	defer func() {
+		exit = os.Exit
+	}()
+
+	t.Run("graceful", func(t *testing.T) {
+		app := New()
+		r := &recorder{}
+		app.OnShutdown("close", r.hook("close", nil))
+
+		signals := make(chan os.Signal, 1)
+		done := make(chan struct{})
+		go func() {
+			app.handleSignals(signals)
+			close(done)
+		}()
+
+		signals <- syscall.SIGTERM
+		<-done
+
+		assert.True(t, app.ShuttingDown())
+		assert.Equal(t, []string{"close"}, r.names())
+		assert.Len(t, codes, 0)
+	})
+	t.Run("second signal", func(t *testing.T) {
+		app := New()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		app.OnShutdown("slow", func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+
+		signals := make(chan os.Signal, 1)
+		done := make(chan struct{})
+		go func() {
+			app.handleSignals(signals)
+			close(done)
+		}()
+
+		signals <- os.Interrupt
+		<-started
+
+		signals <- os.Interrupt
+		assert.Equal(t, 1, <-codes)
+
+		close(release)
+		<-done
+		assert.Nil(t, app.Shutdown(context.Background()))
+	})
+}
@@ -0,0 +1,258 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// structMetadataCache contains metadata of struct types which are analyzed
+// by reflection helpers, keyed by reflect.Type
+var structMetadataCache sync.Map
+
+// structMetadata is the cached metadata of a struct type. Its fields are
+// computed once and must be treated as read-only. Field lookups by name
+// and tag lookups are computed lazily and cached too.
+type structMetadata struct {
+	typ    reflect.Type
+	fields []FieldData
+
+	// byName contains results of FieldByName, including promoted fields
+	// of embedded structs and their index paths, keyed by field name. Only
+	// fields which exist are cached, so lookups of arbitrary names do not
+	// grow the cache.
+	byName sync.Map
+
+	// tags contains values of tags which exist on fields, keyed by tagKey
+	tags sync.Map
+
+	// parsed contains parsed tags of all fields, keyed by tag name
+	parsed sync.Map
+
+	// flattened contains fields of recursive walks, keyed by options
+	flattened sync.Map
+
+	// mapped contains fields of map conversions, keyed by tag name
+	mapped sync.Map
+}
+
+// tagKey is the key of a cached tag value
+type tagKey struct {
+	field string
+	tag   string
+}
+
+// parsedTagsResult is the cached result of parsing a tag on all fields
+type parsedTagsResult struct {
+	tags map[string]Tag
+	err  error
+}
+
+// getStructMetadata returns the cached metadata of given struct type,
+// analyzing the type on first use
+func getStructMetadata(t reflect.Type) *structMetadata {
+	if cached, ok := structMetadataCache.Load(t); ok {
+		return cached.(*structMetadata)
+	}
+
+	metadata := &structMetadata{typ: t, fields: make([]FieldData, t.NumField())}
+	for i := range metadata.fields {
+		field := t.Field(i)
+		metadata.fields[i] = FieldData{
+			Name:      field.Name,
+			Type:      field.Type,
+			Tags:      field.Tag,
+			Exported:  field.PkgPath == "",
+			Anonymous: field.Anonymous,
+			Index:     field.Index,
+			Path:      field.Name,
+		}
+	}
+
+	// Another goroutine may have stored the metadata meanwhile, so the
+	// stored one is used to keep a single instance per type
+	cached, _ := structMetadataCache.LoadOrStore(t, metadata)
+	return cached.(*structMetadata)
+}
+
+// structFieldsMetadata returns the cached fields data of given struct type
+// without values, so it is safe to use for read-only values such as
+// embedded structs of unexported types. Returned slice must not be modified.
+func structFieldsMetadata(t reflect.Type) ([]FieldData, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("argument is not a struct")
+	}
+
+	return getStructMetadata(t).fields, nil
+}
+
+// fieldByName is a cached version of reflect.Type.FieldByName. Index of
+// returned field is a copy, so it could be modified by the caller.
+func (m *structMetadata) fieldByName(name string) (reflect.StructField, bool) {
+	if cached, ok := m.byName.Load(name); ok {
+		field := cached.(reflect.StructField)
+		field.Index = append([]int(nil), field.Index...)
+		return field, true
+	}
+
+	field, found := m.typ.FieldByName(name)
+	if !found {
+		return field, false
+	}
+
+	m.byName.Store(name, field)
+	field.Index = append([]int(nil), field.Index...)
+
+	return field, true
+}
+
+// tag returns the value of given tag on the field with given name, which
+// could be a promoted field
+func (m *structMetadata) tag(field string, tag string) (string, bool) {
+	key := tagKey{field: field, tag: tag}
+	if cached, ok := m.tags.Load(key); ok {
+		return cached.(string), true
+	}
+
+	structField, ok := m.fieldByName(field)
+	if !ok {
+		return "", false
+	}
+
+	value, ok := structField.Tag.Lookup(tag)
+	if !ok {
+		return "", false
+	}
+
+	m.tags.Store(key, value)
+
+	return value, true
+}
+
+// parsedTags returns parsed values of given tag on the fields that have
+// the tag, including promoted fields which are visible by FieldByName,
+// keyed by field name. Returned map must not be modified.
+func (m *structMetadata) parsedTags(tag string) (map[string]Tag, error) {
+	if cached, ok := m.parsed.Load(tag); ok {
+		result := cached.(parsedTagsResult)
+		return result.tags, result.err
+	}
+
+	var result parsedTagsResult
+	tags := make(map[string]Tag)
+
+	for _, field := range reflect.VisibleFields(m.typ) {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+
+		parsed, err := ParseTag(value)
+		if err != nil {
+			result.err = fmt.Errorf("field %s: %v", field.Name, err)
+			break
+		}
+
+		tags[field.Name] = parsed
+	}
+
+	if result.err == nil {
+		result.tags = tags
+	}
+
+	m.parsed.Store(tag, result)
+
+	return result.tags, result.err
+}
+
+// flattenedFields returns the cached fields data of a recursive walk on the
+// struct type with given options. Returned slice must not be modified.
+func (m *structMetadata) flattenedFields(o FieldsDataOptions) []FieldData {
+	if cached, ok := m.flattened.Load(o); ok {
+		return cached.([]FieldData)
+	}
+
+	fields := flattenFields(m.typ, o, map[reflect.Type]bool{m.typ: true})
+	m.flattened.Store(o, fields)
+
+	return fields
+}
+
+// methodSetCache contains exported methods of types, keyed by reflect.Type
+var methodSetCache sync.Map
+
+// methodSet is the cached method set of a type
+type methodSet struct {
+	methods []MethodData
+	byName  map[string]int
+}
+
+// getMethodSet returns the cached method set of given type, analyzing the
+// type on first use
+func getMethodSet(t reflect.Type) *methodSet {
+	if cached, ok := methodSetCache.Load(t); ok {
+		return cached.(*methodSet)
+	}
+
+	set := &methodSet{
+		methods: make([]MethodData, t.NumMethod()),
+		byName:  make(map[string]int, t.NumMethod()),
+	}
+
+	for i := range set.methods {
+		method := t.Method(i)
+
+		// Method types of non-interface types include the receiver as
+		// their first parameter
+		methodType := method.Type
+		if t.Kind() != reflect.Interface {
+			in := make([]reflect.Type, methodType.NumIn()-1)
+			for j := range in {
+				in[j] = methodType.In(j + 1)
+			}
+
+			out := make([]reflect.Type, methodType.NumOut())
+			for j := range out {
+				out[j] = methodType.Out(j)
+			}
+
+			methodType = reflect.FuncOf(in, out, methodType.IsVariadic())
+		}
+
+		set.methods[i] = MethodData{
+			Name:      method.Name,
+			Type:      methodType,
+			Signature: formatType(methodType, false),
+			Index:     method.Index,
+		}
+		set.byName[method.Name] = i
+	}
+
+	cached, _ := methodSetCache.LoadOrStore(t, set)
+	return cached.(*methodSet)
+}
+
+// method returns data of the method with given name
+func (s *methodSet) method(name string) (MethodData, bool) {
+	i, ok := s.byName[name]
+	if !ok {
+		return MethodData{}, false
+	}
+
+	return s.methods[i], true
+}
+
+// mapperFields returns the cached fields of the struct type which are
+// mapped into map keys with given tag. Returned slice must not be
+// modified.
+func (m *structMetadata) mapperFields(tagName string) []mapperField {
+	if cached, ok := m.mapped.Load(tagName); ok {
+		return cached.([]mapperField)
+	}
+
+	fields := resolveMapperFields(m.typ, tagName)
+	m.mapped.Store(tagName, fields)
+
+	return fields
+}
@@ -0,0 +1,184 @@
+package nautilus
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedBase struct {
+	ID string `json:"id" db:"id"`
+}
+
+type cachedModel struct {
+	BaseTaggable
+	cachedBase
+	Name  string `json:"name" db:"name"`
+	Email string `json:"email,omitempty"`
+	Age   int    `json:"age"`
+}
+
+func TestStructMetadataCache(t *testing.T) {
+	t.Run("single instance", func(t *testing.T) {
+		typ := reflect.TypeOf(cachedModel{})
+
+		assert.True(t, getStructMetadata(typ) == getStructMetadata(typ))
+	})
+	t.Run("values are not shared", func(t *testing.T) {
+		first, err := GetStructFieldsData(cachedModel{Name: "first"})
+		assert.Nil(t, err)
+
+		first[2].Value = "changed"
+
+		second, err := GetStructFieldsData(cachedModel{Name: "second"})
+		assert.Nil(t, err)
+		assert.Equal(t, "second", second[2].Value)
+
+		metadata, err := structFieldsMetadata(reflect.TypeOf(cachedModel{}))
+		assert.Nil(t, err)
+		assert.Nil(t, metadata[2].Value)
+	})
+	t.Run("tags", func(t *testing.T) {
+		model := cachedModel{}
+
+		assert.Equal(t, "name", model.GetTag(model, "Name", "db"))
+		assert.Equal(t, "email,omitempty", model.GetTag(&model, "Email", "json"))
+		assert.Equal(t, "id", model.GetTag(model, "ID", "db"))
+		assert.Equal(t, "", model.GetTag(model, "Age", "db"))
+		assert.Equal(t, "", model.GetTag(model, "Missing", "db"))
+		assert.Equal(t, "", model.GetTag(42, "Name", "db"))
+	})
+	t.Run("misses are not cached", func(t *testing.T) {
+		model := cachedModel{}
+		metadata := getStructMetadata(reflect.TypeOf(model))
+
+		assert.Equal(t, "", model.GetTag(model, "Unknown", "db"))
+		assert.Equal(t, "", model.GetTag(model, "Age", "unknown"))
+
+		_, ok := metadata.byName.Load("Unknown")
+		assert.False(t, ok)
+
+		_, ok = metadata.tags.Load(tagKey{field: "Age", tag: "unknown"})
+		assert.False(t, ok)
+	})
+	t.Run("index is copied", func(t *testing.T) {
+		metadata := getStructMetadata(reflect.TypeOf(cachedModel{}))
+
+		field, ok := metadata.fieldByName("ID")
+		assert.True(t, ok)
+		field.Index[0] = 42
+
+		field, ok = metadata.fieldByName("ID")
+		assert.True(t, ok)
+		assert.Equal(t, []int{1, 0}, field.Index)
+
+		fieldsData, err := GetStructFieldsData(cachedModel{})
+		assert.Nil(t, err)
+		fieldsData[2].Index[0] = 42
+
+		options := FieldsDataOptions{FlattenEmbedded: true}
+		expected := append([]int(nil), metadata.flattenedFields(options)[0].Index...)
+
+		fieldsData, err = GetStructFieldsData(cachedModel{}, options)
+		assert.Nil(t, err)
+		fieldsData[0].Index[0] = 42
+
+		assert.Equal(t, []int{2}, metadata.fields[2].Index)
+		assert.Equal(t, expected, metadata.flattenedFields(options)[0].Index)
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				model := &cachedModel{Name: "name"}
+				for j := 0; j < 100; j++ {
+					fieldsData, err := GetStructFieldsData(model)
+					assert.Nil(t, err)
+					assert.Equal(t, "name", fieldsData[2].Value)
+					assert.Equal(t, "id", model.GetTag(model, "ID", "db"))
+
+					value, err := GetFieldValue(model, "Name")
+					assert.Nil(t, err)
+					assert.Equal(t, "name", value)
+				}
+			}()
+		}
+
+		wg.Wait()
+	})
+}
+
+// uncachedGetTag is the implementation of BaseTaggable.GetTag before
+// caching, which is kept for benchmark comparison
+func uncachedGetTag(caller interface{}, field string, tag string) string {
+	t := reflect.TypeOf(caller)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	taggedField, _ := t.FieldByName(field)
+	return taggedField.Tag.Get(tag)
+}
+
+// uncachedGetStructFieldsData is the implementation of GetStructFieldsData
+// before caching, which is kept for benchmark comparison
+func uncachedGetStructFieldsData(i interface{}) []FieldData {
+	t := reflect.TypeOf(i)
+	v := reflect.ValueOf(i)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		v = v.Elem()
+	}
+
+	var ret []FieldData
+	for i := 0; i < t.NumField(); i++ {
+		fieldData := FieldData{
+			Name:      t.Field(i).Name,
+			Type:      t.Field(i).Type,
+			Tags:      t.Field(i).Tag,
+			Exported:  t.Field(i).PkgPath == "",
+			Anonymous: t.Field(i).Anonymous,
+		}
+
+		if fieldData.Exported {
+			fieldData.Value = v.Field(i).Interface()
+		}
+
+		ret = append(ret, fieldData)
+	}
+
+	return ret
+}
+
+func BenchmarkGetTagUncached(b *testing.B) {
+	model := &cachedModel{}
+	for i := 0; i < b.N; i++ {
+		uncachedGetTag(model, "ID", "db")
+	}
+}
+
+func BenchmarkGetTag(b *testing.B) {
+	model := &cachedModel{}
+	for i := 0; i < b.N; i++ {
+		model.GetTag(model, "ID", "db")
+	}
+}
+
+func BenchmarkGetStructFieldsDataUncached(b *testing.B) {
+	model := &cachedModel{}
+	for i := 0; i < b.N; i++ {
+		uncachedGetStructFieldsData(model)
+	}
+}
+
+func BenchmarkGetStructFieldsData(b *testing.B) {
+	model := &cachedModel{}
+	for i := 0; i < b.N; i++ {
+		_, _ = GetStructFieldsData(model)
+	}
+}
@@ -0,0 +1,294 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// convertValue converts given value to a value of given type. Assignable
+// and safe convertible values (e.g. int to int64, string to a named string
+// type or float64 holding a whole number to int) are always converted.
+// When weak is true, conversions between strings, numbers and booleans
+// are also performed, for example "42" to int or 1 to true.
+func convertValue(src reflect.Value, t reflect.Type, weak bool) (reflect.Value, error) {
+	if !src.IsValid() {
+		return reflect.Zero(t), nil
+	}
+
+	st := src.Type()
+	if st.AssignableTo(t) {
+		return src, nil
+	}
+
+	switch {
+	case isNumber(st.Kind()) && isNumber(t.Kind()):
+		return convertNumber(src, t)
+	case isNumber(st.Kind()) && t.Kind() == reflect.String:
+		if weak {
+			return reflect.ValueOf(formatNumber(src)).Convert(t), nil
+		}
+	case st.Kind() == reflect.String && t.Kind() == reflect.String:
+		return src.Convert(t), nil
+	case st.Kind() == reflect.Bool && t.Kind() == reflect.Bool:
+		return src.Convert(t), nil
+	case weak && st.Kind() == reflect.String:
+		return parseWeakString(src.String(), t)
+	case weak && st.Kind() == reflect.Bool:
+		return convertWeakBool(src.Bool(), t)
+	case weak && isNumber(st.Kind()) && t.Kind() == reflect.Bool:
+		return reflect.ValueOf(formatNumber(src) != "0").Convert(t), nil
+	case st.ConvertibleTo(t) && !isNumber(st.Kind()):
+		return src.Convert(t), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", st, t)
+}
+
+// assignValue sets dst to src, converting src to the type of dst using
+// convertValue. Pointers are dereferenced for non-pointer destinations and
+// allocated for pointer destinations. An invalid or nil src sets dst to its
+// zero value.
+func assignValue(dst reflect.Value, src reflect.Value, weak bool) error {
+	if !dst.CanSet() {
+		return errors.New("value is not settable")
+	}
+
+	if !src.IsValid() || isNilValue(src) && !src.Type().AssignableTo(dst.Type()) {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assignValue(elem.Elem(), src, weak); err != nil {
+			return err
+		}
+
+		dst.Set(elem)
+		return nil
+	}
+
+	if src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface {
+		return assignValue(dst, src.Elem(), weak)
+	}
+
+	value, err := convertValue(src, dst.Type(), weak)
+	if err != nil {
+		return err
+	}
+
+	dst.Set(value)
+	return nil
+}
+
+// isNilValue checks if given value is a nil pointer, interface, map, slice,
+// channel or function
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// convertNumber converts a numeric value to another numeric type, making
+// sure the value fits into the target type without losing precision
+func convertNumber(src reflect.Value, t reflect.Type) (reflect.Value, error) {
+	out := reflect.New(t).Elem()
+	overflow := fmt.Errorf("value %s overflows %s", formatNumber(src), t)
+
+	switch {
+	case isInt(t.Kind()):
+		var n int64
+		switch {
+		case isInt(src.Kind()):
+			n = src.Int()
+		case isUint(src.Kind()):
+			if src.Uint() > math.MaxInt64 {
+				return reflect.Value{}, overflow
+			}
+			n = int64(src.Uint())
+		default:
+			f := src.Float()
+			if f != math.Trunc(f) {
+				return reflect.Value{}, fmt.Errorf("cannot convert %s to %s without losing precision", formatNumber(src), t)
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 {
+				return reflect.Value{}, overflow
+			}
+			n = int64(f)
+		}
+
+		if out.OverflowInt(n) {
+			return reflect.Value{}, overflow
+		}
+		out.SetInt(n)
+	case isUint(t.Kind()):
+		var n uint64
+		switch {
+		case isInt(src.Kind()):
+			if src.Int() < 0 {
+				return reflect.Value{}, overflow
+			}
+			n = uint64(src.Int())
+		case isUint(src.Kind()):
+			n = src.Uint()
+		default:
+			f := src.Float()
+			if f != math.Trunc(f) {
+				return reflect.Value{}, fmt.Errorf("cannot convert %s to %s without losing precision", formatNumber(src), t)
+			}
+			if f < 0 || f >= math.MaxUint64 {
+				return reflect.Value{}, overflow
+			}
+			n = uint64(f)
+		}
+
+		if out.OverflowUint(n) {
+			return reflect.Value{}, overflow
+		}
+		out.SetUint(n)
+	default:
+		var f float64
+		switch {
+		case isInt(src.Kind()):
+			f = float64(src.Int())
+		case isUint(src.Kind()):
+			f = float64(src.Uint())
+		default:
+			f = src.Float()
+		}
+
+		if out.OverflowFloat(f) {
+			return reflect.Value{}, overflow
+		}
+		out.SetFloat(f)
+	}
+
+	return out, nil
+}
+
+// parseWeakString parses given string into a value of given type
+func parseWeakString(s string, t reflect.Type) (reflect.Value, error) {
+	s = strings.TrimSpace(s)
+
+	switch {
+	case t == durationType:
+		if s == "" {
+			return reflect.Zero(t), nil
+		}
+
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot parse %q as %s", s, t)
+		}
+
+		return reflect.ValueOf(d), nil
+	case t.Kind() == reflect.Bool:
+		if s == "" {
+			return reflect.Zero(t), nil
+		}
+
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot parse %q as %s", s, t)
+		}
+
+		return reflect.ValueOf(b).Convert(t), nil
+	case isNumber(t.Kind()):
+		if s == "" {
+			return reflect.Zero(t), nil
+		}
+
+		base := integerBase(s)
+		if n, err := strconv.ParseInt(s, base, 64); err == nil {
+			return convertNumber(reflect.ValueOf(n), t)
+		}
+		if n, err := strconv.ParseUint(s, base, 64); err == nil {
+			return convertNumber(reflect.ValueOf(n), t)
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return convertNumber(reflect.ValueOf(f), t)
+		}
+
+		return reflect.Value{}, fmt.Errorf("cannot parse %q as %s", s, t)
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot convert string to %s", t)
+}
+
+// integerBase returns the base for parsing given integer string, which is
+// 10 unless the number has an explicit 0x, 0o or 0b prefix, so numbers
+// with leading zeros such as "010" are not parsed as octal
+func integerBase(s string) int {
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+
+	if len(s) > 2 && s[0] == '0' {
+		switch s[1] {
+		case 'x', 'X', 'o', 'O', 'b', 'B':
+			return 0
+		}
+	}
+
+	return 10
+}
+
+// convertWeakBool converts given boolean into number (1 or 0) or string
+func convertWeakBool(b bool, t reflect.Type) (reflect.Value, error) {
+	switch {
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(strconv.FormatBool(b)).Convert(t), nil
+	case isNumber(t.Kind()):
+		n := 0
+		if b {
+			n = 1
+		}
+
+		return convertNumber(reflect.ValueOf(n), t)
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot convert bool to %s", t)
+}
+
+// formatNumber formats a numeric value as string
+func formatNumber(v reflect.Value) string {
+	switch {
+	case isInt(v.Kind()):
+		return strconv.FormatInt(v.Int(), 10)
+	case isUint(v.Kind()):
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+func isNumber(k reflect.Kind) bool {
+	return isInt(k) || isUint(k) || isFloat(k)
+}
@@ -0,0 +1,335 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// DeepCopy returns a deep copy of given value with the same type. Pointers,
+// slices, maps and interfaces are cloned recursively and shared or cyclic
+// references are preserved in the copy, so a pointer that refers to itself
+// results in a copy that refers to itself. Unexported struct fields can
+// not be set by reflection, so they are copied shallowly, while exported
+// fields of unexported embedded structs are still copied deeply. Channels
+// and functions are copied as is.
+func DeepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	src := reflect.ValueOf(v)
+	dst := reflect.New(src.Type()).Elem()
+	newCopier().copy(dst, src)
+
+	return dst.Interface()
+}
+
+// copyKey identifies a reference which has been copied. Slices sharing the
+// same array with different lengths are different references.
+type copyKey struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// copier deep copies values, keeping track of copied references
+type copier struct {
+	copied map[copyKey]reflect.Value
+}
+
+func newCopier() *copier {
+	return &copier{copied: make(map[copyKey]reflect.Value)}
+}
+
+// copy sets dst, which must be a settable zero value of the same type as
+// src, to a deep copy of src
+func (c *copier) copy(dst reflect.Value, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+
+		key := copyKey{typ: src.Type(), ptr: src.Pointer()}
+		if copied, ok := c.copied[key]; ok {
+			dst.Set(copied)
+			return
+		}
+
+		ptr := reflect.New(src.Type().Elem())
+		c.copied[key] = ptr
+		c.copy(ptr.Elem(), src.Elem())
+		dst.Set(ptr)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+
+		elem := reflect.New(src.Elem().Type()).Elem()
+		c.copy(elem, src.Elem())
+		dst.Set(elem)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+
+		key := copyKey{typ: src.Type(), ptr: src.Pointer(), len: src.Len()}
+		if copied, ok := c.copied[key]; ok {
+			dst.Set(copied)
+			return
+		}
+
+		slice := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		c.copied[key] = slice
+		for i := 0; i < src.Len(); i++ {
+			c.copy(slice.Index(i), src.Index(i))
+		}
+
+		dst.Set(slice)
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+
+		key := copyKey{typ: src.Type(), ptr: src.Pointer()}
+		if copied, ok := c.copied[key]; ok {
+			dst.Set(copied)
+			return
+		}
+
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		c.copied[key] = m
+
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			c.copy(k, iter.Key())
+
+			v := reflect.New(src.Type().Elem()).Elem()
+			c.copy(v, iter.Value())
+
+			m.SetMapIndex(k, v)
+		}
+
+		dst.Set(m)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Struct:
+		// Unexported fields are copied shallowly by copying the whole struct
+		if dst.CanSet() {
+			dst.Set(src)
+		}
+
+		c.copyFields(dst, src)
+	default:
+		dst.Set(src)
+	}
+}
+
+// copyFields deep copies fields of src struct into dst struct, skipping the
+// fields which are not settable. Exported fields of unexported embedded
+// structs are settable, so they are copied too.
+func (c *copier) copyFields(dst reflect.Value, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		field := dst.Field(i)
+
+		switch {
+		case field.CanSet():
+			c.copy(field, src.Field(i))
+		case field.Kind() == reflect.Struct:
+			c.copyFields(field, src.Field(i))
+		}
+	}
+}
+
+// MapStruct copies fields of src struct (or pointer to struct) into the
+// struct that dst points to. Fields are matched by their names in `json`
+// tag (or TagName option), falling back to field names, and names are
+// compared in snake case, so "UserID", "UserId" and "user_id" all match.
+// Fields tagged with "-" are skipped and fields of embedded structs are
+// promoted the same way ToJSONMap does, so embedded structs with a name in
+// their tag are mapped as a single field.
+//
+// Values are converted to the destination type like SetFieldValue does,
+// for example *string to string or int to int64, and WeaklyTypedInput
+// option enables conversions between strings, numbers and booleans.
+// Nested structs, slices and maps of different types are mapped
+// recursively and other values are deep copied, so dst does not share any
+// reference with src.
+func MapStruct(src interface{}, dst interface{}, opts ...MapperOptions) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("output must be a non-nil pointer to a struct")
+	}
+
+	sv := reflect.ValueOf(src)
+	for sv.Kind() == reflect.Ptr && !sv.IsNil() {
+		sv = sv.Elem()
+	}
+
+	if sv.Kind() != reflect.Struct {
+		return errors.New("input must be a struct or a non-nil pointer to a struct")
+	}
+
+	m := &structMapper{options: mergeMapperOptions(opts), copier: newCopier()}
+
+	return m.mapStruct(rv.Elem(), sv, "")
+}
+
+// structMapper maps values of different types into each other
+type structMapper struct {
+	options MapperOptions
+	copier  *copier
+}
+
+func (m *structMapper) mapStruct(dst reflect.Value, src reflect.Value, path string) error {
+	srcFields := make(map[string]mapperField)
+	for _, f := range getStructMetadata(src.Type()).mapperFields(m.options.TagName) {
+		srcFields[ToSnake(f.name)] = f
+	}
+
+	for _, f := range getStructMetadata(dst.Type()).mapperFields(m.options.TagName) {
+		srcField, ok := srcFields[ToSnake(f.name)]
+		if !ok {
+			continue
+		}
+
+		value, ok := fieldByIndex(src, srcField.index)
+		if !ok {
+			continue
+		}
+
+		fieldPath := joinFieldPath(path, dst.Type().FieldByIndex(f.index).Name)
+
+		field, err := allocFieldByIndex(dst, f.index)
+		if err != nil {
+			return fmt.Errorf("%s: %v", fieldPath, err)
+		}
+
+		if err := m.mapValue(field, value, fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapValue sets dst to a copy of src, mapping or converting it if their
+// types are different
+func (m *structMapper) mapValue(dst reflect.Value, src reflect.Value, path string) error {
+	if !src.IsValid() || isNilValue(src) {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		value := reflect.New(src.Type()).Elem()
+		m.copier.copy(value, src)
+		dst.Set(value)
+
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if err := m.mapValue(elem.Elem(), src, path); err != nil {
+			return err
+		}
+
+		dst.Set(elem)
+		return nil
+	}
+
+	if src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface {
+		return m.mapValue(dst, src.Elem(), path)
+	}
+
+	switch {
+	case dst.Kind() == reflect.Struct && src.Kind() == reflect.Struct && dst.Type() != timeType && src.Type() != timeType:
+		return m.mapStruct(dst, src, path)
+	case dst.Kind() == reflect.Slice && (src.Kind() == reflect.Slice || src.Kind() == reflect.Array):
+		slice := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := m.mapValue(slice.Index(i), src.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		dst.Set(slice)
+		return nil
+	case dst.Kind() == reflect.Array && (src.Kind() == reflect.Slice || src.Kind() == reflect.Array):
+		if src.Len() > dst.Len() {
+			return fmt.Errorf("%s: cannot map %d elements into %s", path, src.Len(), dst.Type())
+		}
+
+		for i := 0; i < src.Len(); i++ {
+			if err := m.mapValue(dst.Index(i), src.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case dst.Kind() == reflect.Map && src.Kind() == reflect.Map:
+		return m.mapMap(dst, src, path)
+	}
+
+	if err := assignValue(dst, src, m.options.WeaklyTypedInput); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	return nil
+}
+
+func (m *structMapper) mapMap(dst reflect.Value, src reflect.Value, path string) error {
+	result := reflect.MakeMapWithSize(dst.Type(), src.Len())
+
+	keys := src.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+
+	for _, key := range keys {
+		elemPath := fmt.Sprintf("%s[%v]", path, key)
+
+		k := reflect.New(dst.Type().Key()).Elem()
+		if err := m.mapValue(k, key, elemPath); err != nil {
+			return err
+		}
+
+		v := reflect.New(dst.Type().Elem()).Elem()
+		if err := m.mapValue(v, src.MapIndex(key), elemPath); err != nil {
+			return err
+		}
+
+		result.SetMapIndex(k, v)
+	}
+
+	dst.Set(result)
+	return nil
+}
+
+// allocFieldByIndex is like reflect.Value.FieldByIndex, but allocates nil
+// embedded pointers along the path
+func allocFieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,240 @@
+package nautilus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type copyNode struct {
+	Value    int
+	Next     *copyNode
+	Children []*copyNode
+}
+
+type copyHidden struct {
+	Tags []string
+	note string
+}
+
+type copySample struct {
+	copyHidden
+	Name     string
+	Pointer  *int
+	Slice    []int
+	Array    [2][]int
+	Map      map[string][]int
+	Any      interface{}
+	Time     time.Time
+	Func     func() int
+	internal []int
+}
+
+func TestDeepCopy(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		n := 42
+		src := &copySample{
+			copyHidden: copyHidden{Tags: []string{"a"}, note: "note"},
+			Name:       "name",
+			Pointer:    &n,
+			Slice:      []int{1, 2},
+			Array:      [2][]int{{1}, {2}},
+			Map:        map[string][]int{"a": {1}},
+			Any:        []int{3},
+			Time:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			Func:       func() int { return 1 },
+			internal:   []int{4},
+		}
+
+		dst := DeepCopy(src).(*copySample)
+
+		assert.False(t, src == dst)
+		assert.Equal(t, src.Name, dst.Name)
+		assert.Equal(t, src.Time, dst.Time)
+		assert.Equal(t, "note", dst.note)
+		assert.Equal(t, 1, dst.Func())
+
+		// Modifying the copy does not affect the source
+		*dst.Pointer = 0
+		dst.Slice[0] = 0
+		dst.Array[0][0] = 0
+		dst.Map["a"][0] = 0
+		dst.Any.([]int)[0] = 0
+		dst.Tags[0] = "b"
+
+		assert.Equal(t, 42, n)
+		assert.Equal(t, []int{1, 2}, src.Slice)
+		assert.Equal(t, [2][]int{{1}, {2}}, src.Array)
+		assert.Equal(t, map[string][]int{"a": {1}}, src.Map)
+		assert.Equal(t, []int{3}, src.Any)
+		assert.Equal(t, []string{"a"}, src.Tags)
+
+		// Unexported fields are copied shallowly
+		dst.internal[0] = 0
+		assert.Equal(t, []int{0}, src.internal)
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, DeepCopy(nil))
+		assert.Equal(t, (*copyNode)(nil), DeepCopy((*copyNode)(nil)))
+		assert.Equal(t, []int(nil), DeepCopy([]int(nil)))
+		assert.Equal(t, copySample{}, DeepCopy(copySample{}))
+	})
+	t.Run("cycles", func(t *testing.T) {
+		root := &copyNode{Value: 1}
+		child := &copyNode{Value: 2, Next: root}
+		root.Next = root
+		root.Children = []*copyNode{child, child}
+
+		dst := DeepCopy(root).(*copyNode)
+
+		assert.False(t, dst == root)
+		assert.True(t, dst.Next == dst)
+		assert.True(t, dst.Children[0] == dst.Children[1])
+		assert.True(t, dst.Children[0].Next == dst)
+		assert.False(t, dst.Children[0] == child)
+	})
+	t.Run("self referencing map", func(t *testing.T) {
+		m := map[string]interface{}{"a": 1}
+		m["self"] = m
+
+		dst := DeepCopy(m).(map[string]interface{})
+		dst["a"] = 2
+
+		assert.Equal(t, 1, m["a"])
+		assert.Equal(t, 2, dst["self"].(map[string]interface{})["a"])
+	})
+}
+
+type MapAudit struct {
+	CreatedBy string
+}
+
+type mapAddressDTO struct {
+	City *string `json:"city"`
+}
+
+type mapAddress struct {
+	City string
+}
+
+type mapUserDTO struct {
+	MapAudit
+	UserID    int               `json:"user_id"`
+	FullName  *string           `json:"name"`
+	Age       string            `json:"age"`
+	Address   *mapAddressDTO    `json:"address"`
+	Addresses []mapAddressDTO   `json:"addresses"`
+	Scores    map[string]int    `json:"scores"`
+	Tags      []string          `json:"tags"`
+	Password  string            `json:"-"`
+	Extra     map[string]string `json:"extra"`
+}
+
+type mapUser struct {
+	*MapAudit
+	UserId    int64
+	Name      string
+	Age       int
+	Address   mapAddress
+	Addresses []*mapAddress
+	Scores    map[string]float64
+	Tags      []string
+	Password  string
+	Extra     map[string]string
+	Untouched string
+}
+
+func TestMapStruct(t *testing.T) {
+	name, city := "John", "Tehran"
+	dto := mapUserDTO{
+		MapAudit:  MapAudit{CreatedBy: "admin"},
+		UserID:    7,
+		FullName:  &name,
+		Age:       "30",
+		Address:   &mapAddressDTO{City: &city},
+		Addresses: []mapAddressDTO{{City: &city}, {}},
+		Scores:    map[string]int{"math": 20},
+		Tags:      []string{"a"},
+		Password:  "secret",
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		user := mapUser{Untouched: "kept", Extra: map[string]string{"a": "b"}}
+		err := MapStruct(&dto, &user)
+
+		assert.EqualError(t, err, "Age: cannot convert string to int")
+	})
+	t.Run("weak", func(t *testing.T) {
+		user := mapUser{Untouched: "kept", Extra: map[string]string{"a": "b"}}
+		err := MapStruct(dto, &user, MapperOptions{WeaklyTypedInput: true})
+		assert.Nil(t, err)
+
+		assert.Equal(t, mapUser{
+			MapAudit:  &MapAudit{CreatedBy: "admin"},
+			UserId:    7,
+			Name:      "John",
+			Age:       30,
+			Address:   mapAddress{City: "Tehran"},
+			Addresses: []*mapAddress{{City: "Tehran"}, {}},
+			Scores:    map[string]float64{"math": 20},
+			Tags:      []string{"a"},
+			Untouched: "kept",
+		}, user)
+
+		// Copied values do not share references
+		user.Tags[0] = "b"
+		assert.Equal(t, []string{"a"}, dto.Tags)
+	})
+	t.Run("reverse", func(t *testing.T) {
+		user := mapUser{UserId: 1, Name: "Jane", Age: 20, Address: mapAddress{City: "Shiraz"}}
+
+		var result mapUserDTO
+		assert.Nil(t, MapStruct(user, &result, MapperOptions{WeaklyTypedInput: true}))
+
+		assert.Equal(t, 1, result.UserID)
+		assert.Equal(t, "Jane", *result.FullName)
+		assert.Equal(t, "20", result.Age)
+		assert.Equal(t, "Shiraz", *result.Address.City)
+		assert.Equal(t, "", result.CreatedBy)
+	})
+	t.Run("named embedded", func(t *testing.T) {
+		type source struct {
+			MapAudit  `json:"audit"`
+			CreatedBy string `json:"created_by"`
+		}
+
+		type target struct {
+			Audit     MapAudit
+			CreatedBy string
+		}
+
+		var result target
+		assert.Nil(t, MapStruct(source{MapAudit: MapAudit{CreatedBy: "admin"}, CreatedBy: "user"}, &result))
+		assert.Equal(t, target{Audit: MapAudit{CreatedBy: "admin"}, CreatedBy: "user"}, result)
+	})
+	t.Run("errors", func(t *testing.T) {
+		var user mapUser
+
+		assert.EqualError(t, MapStruct(dto, user), "output must be a non-nil pointer to a struct")
+		assert.EqualError(t, MapStruct(42, &user), "input must be a struct or a non-nil pointer to a struct")
+
+		type small struct {
+			UserID int8 `json:"user_id"`
+		}
+
+		err := MapStruct(mapUserDTO{UserID: 300}, &small{})
+		assert.EqualError(t, err, "UserID: value 300 overflows int8")
+
+		type hiddenAudit struct {
+			CreatedBy string
+		}
+
+		type hidden struct {
+			*hiddenAudit
+		}
+
+		err = MapStruct(dto, &hidden{})
+		assert.EqualError(t, err, "CreatedBy: cannot set embedded pointer to unexported struct nautilus.hiddenAudit")
+	})
+}
@@ -0,0 +1,140 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ApplyDefaults sets fields of the struct that v points to from their
+// `default` tags. Only empty fields (as defined by IsEmpty) are set, so
+// values which are already populated (e.g. by decoding a request) are
+// kept, while empty non-nil slices and maps get their defaults. Non-nil
+// pointers are kept even if they point to an empty value, since they are
+// set explicitly. Tag values are parsed like the `default` tag of LoadEnv, so
+// numbers, booleans, durations, URLs, pointers, text unmarshalers, slices
+// and maps (in "key:value,key:value" format) are supported, and slice and
+// map items are separated by the `envSeparator` tag or comma.
+//
+// Nested and embedded structs are processed recursively, as well as struct
+// elements of slices. A nil pointer to a nested struct is allocated only if
+// any default is applied to its fields.
+func ApplyDefaults(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("input must be a non-nil pointer to a struct")
+	}
+
+	d := &defaulter{
+		allocating: make(map[reflect.Type]bool),
+		visited:    map[uintptr]bool{rv.Pointer(): true},
+	}
+
+	return d.applyStruct(rv.Elem(), "")
+}
+
+// defaulter keeps the state of applying defaults to a struct recursively
+type defaulter struct {
+	// allocating contains types of the nil pointers which are allocated
+	// and being processed, to avoid allocating recursive types infinitely
+	allocating map[reflect.Type]bool
+
+	// visited contains addresses of the processed pointers, to stop on
+	// cyclic references
+	visited map[uintptr]bool
+}
+
+// applyStruct applies defaults to fields of given struct
+func (d *defaulter) applyStruct(v reflect.Value, path string) error {
+	fieldsData, err := structFieldsMetadata(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for i, fieldData := range fieldsData {
+		// Exported fields of unexported embedded structs are settable too
+		if !fieldData.Exported && !(fieldData.Anonymous && fieldData.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		field := v.Field(i)
+		fieldPath := joinFieldPath(path, fieldData.Name)
+		if fieldData.Anonymous {
+			fieldPath = path
+		}
+
+		value, ok := fieldData.Tags.Lookup("default")
+		if value == "-" {
+			continue
+		}
+
+		if ok && field.CanSet() && needsDefault(field) {
+			if err := setFromString(field, value, fieldData.Tags.Get("envSeparator")); err != nil {
+				return fmt.Errorf("%s: invalid default value %q: %v", joinFieldPath(path, fieldData.Name), value, err)
+			}
+
+			continue
+		}
+
+		if err := d.applyNested(field, fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyNested applies defaults to given nested struct, pointer to struct
+// or slice of structs
+func (d *defaulter) applyNested(v reflect.Value, path string) error {
+	switch {
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		if !isNestedStruct(v.Type().Elem()) {
+			return nil
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if err := d.applyNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case !isNestedStruct(v.Type()):
+		return nil
+	case v.Kind() == reflect.Struct:
+		return d.applyStruct(v, path)
+	case !v.IsNil():
+		if d.visited[v.Pointer()] {
+			return nil
+		}
+
+		d.visited[v.Pointer()] = true
+		return d.applyStruct(v.Elem(), path)
+	case v.CanSet() && !d.allocating[v.Type().Elem()]:
+		t := v.Type().Elem()
+		elem := reflect.New(t)
+
+		d.allocating[t] = true
+		err := d.applyStruct(elem.Elem(), path)
+		delete(d.allocating, t)
+
+		if err != nil {
+			return err
+		}
+
+		if !isZeroValue(elem.Elem()) {
+			v.Set(elem)
+		}
+	}
+
+	return nil
+}
+
+// needsDefault checks whether given field is empty and should be set to
+// its default value. Pointers need a default only if they are nil.
+func needsDefault(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		return v.IsNil()
+	}
+
+	return isEmptyValue(v, false)
+}
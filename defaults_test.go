@@ -0,0 +1,108 @@
+package nautilus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type defaultsLimits struct {
+	Rate  float64 `default:"1.5"`
+	Burst int     `default:"10"`
+}
+
+type defaultsServer struct {
+	Host string `default:"localhost"`
+	Port int    `default:"80"`
+}
+
+type defaultsBase struct {
+	Version string `default:"v1"`
+}
+
+type defaultsNode struct {
+	Name string
+	Next *defaultsNode
+}
+
+type defaultsConfig struct {
+	defaultsBase
+	Name     string            `default:"app"`
+	Debug    bool              `default:"true"`
+	Timeout  time.Duration     `default:"5s"`
+	Port     *int              `default:"8080"`
+	Hosts    []string          `default:"a, b"`
+	Ports    []int             `default:"1;2" envSeparator:";"`
+	Labels   map[string]string `default:"env:dev,team:core"`
+	Limits   defaultsLimits
+	Primary  *defaultsServer
+	Servers  []defaultsServer
+	Node     *defaultsNode
+	Skipped  string `default:"-"`
+	internal string `default:"hidden"`
+}
+
+func TestApplyDefaults(t *testing.T) {
+	t.Run("zero values", func(t *testing.T) {
+		var config defaultsConfig
+		assert.Nil(t, ApplyDefaults(&config))
+
+		port := 8080
+		assert.Equal(t, defaultsConfig{
+			defaultsBase: defaultsBase{Version: "v1"},
+			Name:         "app",
+			Debug:        true,
+			Timeout:      5 * time.Second,
+			Port:         &port,
+			Hosts:        []string{"a", "b"},
+			Ports:        []int{1, 2},
+			Labels:       map[string]string{"env": "dev", "team": "core"},
+			Limits:       defaultsLimits{Rate: 1.5, Burst: 10},
+			Primary:      &defaultsServer{Host: "localhost", Port: 80},
+		}, config)
+	})
+	t.Run("populated values", func(t *testing.T) {
+		port := 0
+		config := defaultsConfig{
+			Name:    "custom",
+			Port:    &port,
+			Hosts:   []string{},
+			Labels:  map[string]string{},
+			Limits:  defaultsLimits{Burst: 3},
+			Servers: []defaultsServer{{Port: 443}, {Host: "example.com"}},
+		}
+		assert.Nil(t, ApplyDefaults(&config))
+
+		assert.Equal(t, "custom", config.Name)
+		assert.Equal(t, 0, *config.Port)
+		assert.Equal(t, []string{"a", "b"}, config.Hosts)
+		assert.Equal(t, map[string]string{"env": "dev", "team": "core"}, config.Labels)
+		assert.Equal(t, defaultsLimits{Rate: 1.5, Burst: 3}, config.Limits)
+		assert.Equal(t, []defaultsServer{
+			{Host: "localhost", Port: 443},
+			{Host: "example.com", Port: 80},
+		}, config.Servers)
+		assert.Nil(t, config.Node)
+	})
+	t.Run("cycles", func(t *testing.T) {
+		node := &defaultsNode{Name: "a"}
+		node.Next = node
+
+		config := defaultsConfig{Node: node}
+		assert.Nil(t, ApplyDefaults(&config))
+		assert.True(t, config.Node.Next == node)
+	})
+	t.Run("errors", func(t *testing.T) {
+		assert.EqualError(t, ApplyDefaults(defaultsConfig{}), "input must be a non-nil pointer to a struct")
+
+		type invalid struct {
+			Limits struct {
+				Burst int `default:"many"`
+			}
+		}
+
+		err := ApplyDefaults(&invalid{})
+		assert.EqualError(t, err, `Limits.Burst: invalid default value "many": cannot parse "many" as int`)
+	})
+}
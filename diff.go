@@ -0,0 +1,413 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces old and new values of changed secret fields
+const RedactedValue = "[REDACTED]"
+
+// Change is a single changed value between two versions of a struct
+type Change struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// diffOptions contains options of a field read from its `diff` tag
+type diffOptions struct {
+	skip   bool
+	secret bool
+	key    string
+}
+
+// DiffStructs returns changed values between two versions of a struct,
+// which must be structs (or pointers to structs) of the same type. A nil
+// pointer is compared as the zero value of the struct.
+//
+// Nested structs and maps are compared recursively and their changes have
+// paths such as "Address.City" and "Meta[key]". Slices are compared by
+// index, e.g. "Items[2].Price", unless the field has `diff:"key=ID"` tag,
+// in which case struct elements are matched by the value of given field
+// and their path contains it, e.g. "Items[ID=3].Price". Added and removed
+// elements and keys are reported with nil as their old or new value.
+// Fields of embedded structs are reported as promoted fields. Cyclic
+// references are followed until the same pair of references is reached
+// again.
+//
+// Fields with `diff:"-"` tag are skipped and the values of changed fields
+// with `diff:"secret"` tag are replaced by RedactedValue. Options could be
+// combined, e.g. `diff:"secret,key=ID"`.
+func DiffStructs(a interface{}, b interface{}) ([]Change, error) {
+	av, err := diffStructValue(a)
+	if err != nil {
+		return nil, err
+	}
+
+	bv, err := diffStructValue(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if av.Type() != bv.Type() {
+		return nil, fmt.Errorf("cannot diff %s with %s", av.Type(), bv.Type())
+	}
+
+	d := &differ{comparing: make(map[diffPair]bool)}
+	if err := d.diffStruct(av, bv, ""); err != nil {
+		return nil, err
+	}
+
+	return d.changes, nil
+}
+
+// diffPair identifies a pair of references which are being compared.
+// Slices sharing the same array with different lengths are different
+// references.
+type diffPair struct {
+	typ        reflect.Type
+	a, b       uintptr
+	aLen, bLen int
+}
+
+// differ compares values, keeping track of the pairs of references which
+// are being compared to stop on cycles. A pair which is reached again
+// through a cycle adds no change, since its changes are reported by the
+// comparison which is in progress.
+type differ struct {
+	changes   []Change
+	comparing map[diffPair]bool
+}
+
+// enter marks the pair of given pointers, maps or slices as being compared
+// and returns a function which unmarks it, or false if the pair is being
+// compared already
+func (d *differ) enter(a reflect.Value, b reflect.Value) (func(), bool) {
+	pair := diffPair{typ: a.Type(), a: a.Pointer(), b: b.Pointer()}
+	if a.Kind() == reflect.Slice {
+		pair.aLen, pair.bLen = a.Len(), b.Len()
+	}
+
+	if d.comparing[pair] {
+		return nil, false
+	}
+
+	d.comparing[pair] = true
+
+	return func() { delete(d.comparing, pair) }, true
+}
+
+func (d *differ) add(change Change) {
+	d.changes = append(d.changes, change)
+}
+
+// diffStructValue returns the struct value of given struct or pointer
+func diffStructValue(i interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(i)
+	if v.Kind() == reflect.Ptr {
+		if v.Type().Elem().Kind() == reflect.Struct && v.IsNil() {
+			return reflect.Zero(v.Type().Elem()), nil
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("arguments must be structs or pointers to structs")
+	}
+
+	return v, nil
+}
+
+func (d *differ) diffStruct(a reflect.Value, b reflect.Value, path string) error {
+	metadata := getStructMetadata(a.Type())
+
+	tags, err := metadata.parsedTags("diff")
+	if err != nil {
+		return err
+	}
+
+	for i, fieldData := range metadata.fields {
+		options := parseDiffOptions(tags[fieldData.Name])
+		if options.skip {
+			continue
+		}
+
+		// Fields of embedded structs are promoted into the parent path
+		if fieldData.Anonymous && fieldData.Tags.Get("diff") == "" && isNestedStruct(fieldData.Type) {
+			if err := d.diffStruct(embeddedStructValue(a.Field(i)), embeddedStructValue(b.Field(i)), path); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !fieldData.Exported {
+			continue
+		}
+
+		fieldPath := joinFieldPath(path, fieldData.Name)
+		if err := d.diffValues(a.Field(i), b.Field(i), fieldPath, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// embeddedStructValue returns the struct value of given embedded field. Nil
+// embedded pointers are compared as the zero value of the struct, the same
+// way their promoted fields are read.
+func embeddedStructValue(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Ptr {
+		return v
+	}
+
+	if v.IsNil() {
+		return reflect.Zero(v.Type().Elem())
+	}
+
+	return v.Elem()
+}
+
+func (d *differ) diffValues(a reflect.Value, b reflect.Value, path string, options diffOptions) error {
+	if options.secret {
+		if !diffEqual(a, b) {
+			d.add(Change{Path: path, Old: RedactedValue, New: RedactedValue})
+		}
+
+		return nil
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if a.IsNil() || b.IsNil() {
+			break
+		}
+
+		leave, ok := d.enter(a, b)
+		if !ok {
+			return nil
+		}
+		defer leave()
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() || a.Elem().Type() != b.Elem().Type() {
+			break
+		}
+
+		return d.diffValues(a.Elem(), b.Elem(), path, options)
+	case reflect.Struct:
+		if !isNestedStruct(a.Type()) {
+			break
+		}
+
+		return d.diffStruct(a, b, path)
+	case reflect.Slice, reflect.Array:
+		if options.key != "" {
+			return d.diffKeyedSlices(a, b, path, options.key)
+		}
+
+		return d.diffSlices(a, b, path)
+	case reflect.Map:
+		return d.diffMaps(a, b, path)
+	}
+
+	if !diffEqual(a, b) {
+		d.add(Change{Path: path, Old: interfaceOf(a), New: interfaceOf(b)})
+	}
+
+	return nil
+}
+
+func (d *differ) diffSlices(a reflect.Value, b reflect.Value, path string) error {
+	for i := 0; i < a.Len() || i < b.Len(); i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+
+		switch {
+		case i >= b.Len():
+			d.add(Change{Path: elemPath, Old: interfaceOf(a.Index(i))})
+		case i >= a.Len():
+			d.add(Change{Path: elemPath, New: interfaceOf(b.Index(i))})
+		default:
+			if err := d.diffValues(a.Index(i), b.Index(i), elemPath, diffOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// diffKeyedSlices compares elements of given slices which have the same
+// value in their key field
+func (d *differ) diffKeyedSlices(a reflect.Value, b reflect.Value, path string, key string) error {
+	aKeys, err := sliceKeys(a, key)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	bKeys, err := sliceKeys(b, key)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	bIndex := make(map[interface{}]int, len(bKeys))
+	for i, k := range bKeys {
+		bIndex[k] = i
+	}
+
+	for i, k := range aKeys {
+		elemPath := fmt.Sprintf("%s[%s=%v]", path, key, k)
+
+		j, ok := bIndex[k]
+		if !ok {
+			d.add(Change{Path: elemPath, Old: interfaceOf(a.Index(i))})
+			continue
+		}
+
+		if err := d.diffValues(a.Index(i), b.Index(j), elemPath, diffOptions{}); err != nil {
+			return err
+		}
+
+		delete(bIndex, k)
+	}
+
+	for j, k := range bKeys {
+		if _, ok := bIndex[k]; ok {
+			elemPath := fmt.Sprintf("%s[%s=%v]", path, key, k)
+			d.add(Change{Path: elemPath, New: interfaceOf(b.Index(j))})
+		}
+	}
+
+	return nil
+}
+
+// sliceKeys returns values of key field of struct elements of given slice
+func sliceKeys(v reflect.Value, key string) ([]interface{}, error) {
+	keys := make([]interface{}, v.Len())
+	for i := range keys {
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+
+		if elem.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("cannot read key %s of %s element", key, v.Index(i).Kind())
+		}
+
+		field, ok := getStructMetadata(elem.Type()).fieldByName(key)
+		if !ok || field.PkgPath != "" || !field.Type.Comparable() {
+			return nil, fmt.Errorf("invalid key field %s of %s", key, elem.Type())
+		}
+
+		value, ok := fieldByIndex(elem, field.Index)
+		if !ok {
+			return nil, fmt.Errorf("key field %s of element %d is promoted through a nil pointer", key, i)
+		}
+
+		if !value.CanInterface() {
+			return nil, fmt.Errorf("cannot read key field %s of unexported %s", key, elem.Type())
+		}
+
+		keys[i] = value.Interface()
+	}
+
+	return keys, nil
+}
+
+func (d *differ) diffMaps(a reflect.Value, b reflect.Value, path string) error {
+	keys := a.MapKeys()
+	for _, key := range b.MapKeys() {
+		if !a.MapIndex(key).IsValid() {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+
+	for _, key := range keys {
+		elemPath := fmt.Sprintf("%s[%v]", path, key)
+		oldValue, newValue := a.MapIndex(key), b.MapIndex(key)
+
+		switch {
+		case !newValue.IsValid():
+			d.add(Change{Path: elemPath, Old: interfaceOf(oldValue)})
+		case !oldValue.IsValid():
+			d.add(Change{Path: elemPath, New: interfaceOf(newValue)})
+		default:
+			if err := d.diffValues(oldValue, newValue, elemPath, diffOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// diffEqual checks equality of two values. Values having an `Equal` method,
+// such as time.Time, are compared by that method. Values obtained from
+// unexported fields could not be read, so they are considered equal.
+func diffEqual(a reflect.Value, b reflect.Value) bool {
+	if !a.CanInterface() || !b.CanInterface() {
+		return true
+	}
+
+	if a.Kind() == reflect.Struct {
+		if method := a.MethodByName("Equal"); method.IsValid() {
+			t := method.Type()
+			if t.NumIn() == 1 && t.In(0) == b.Type() && t.NumOut() == 1 && t.Out(0).Kind() == reflect.Bool {
+				return method.Call([]reflect.Value{b})[0].Bool()
+			}
+		}
+	}
+
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// interfaceOf returns the value of v as an interface, or nil if it is
+// obtained from an unexported field
+func interfaceOf(v reflect.Value) interface{} {
+	if !v.CanInterface() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+// parseDiffOptions reads diff options from the parsed `diff` tag
+func parseDiffOptions(tag Tag) diffOptions {
+	var options diffOptions
+
+	items := tag.Options
+	if tag.Name != "" {
+		parts := strings.SplitN(tag.Name, "=", 2)
+		item := TagOption{Key: parts[0]}
+		if len(parts) == 2 {
+			item.Value, item.HasValue = parts[1], true
+		}
+
+		items = append([]TagOption{item}, items...)
+	}
+
+	for _, item := range items {
+		switch item.Key {
+		case "-":
+			options.skip = true
+		case "secret":
+			options.secret = true
+		case "key":
+			options.key = item.Value
+		}
+	}
+
+	return options
+}
@@ -0,0 +1,195 @@
+package nautilus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type DiffAudit struct {
+	UpdatedBy string
+}
+
+type diffItem struct {
+	ID    int
+	Price float64
+}
+
+type diffAddress struct {
+	City string
+	Zip  string
+}
+
+type diffEntity struct {
+	DiffAudit
+	Name      string
+	Password  string `diff:"secret"`
+	Version   int    `diff:"-"`
+	Address   diffAddress
+	Billing   *diffAddress
+	Tags      []string
+	Items     []diffItem  `diff:"key=ID"`
+	Refs      []*diffItem `diff:"key=ID"`
+	Meta      map[string]interface{}
+	UpdatedAt time.Time
+	Any       interface{}
+	internal  string
+}
+
+type diffInner struct {
+	A int
+}
+
+type diffOuter struct {
+	*diffInner
+	X int
+}
+
+// diffText is an unexported struct which is not walked as a nested
+// struct, since it unmarshals itself
+type diffText struct {
+	value string
+}
+
+func (d *diffText) UnmarshalText(text []byte) error {
+	d.value = string(text)
+	return nil
+}
+
+type diffOpaque struct {
+	diffText
+	Name string
+}
+
+func TestDiffStructs(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	old := diffEntity{
+		DiffAudit: DiffAudit{UpdatedBy: "admin"},
+		Name:      "name",
+		Password:  "old",
+		Version:   1,
+		Address:   diffAddress{City: "Tehran", Zip: "1"},
+		Tags:      []string{"a", "b", "c"},
+		Items:     []diffItem{{ID: 1, Price: 10}, {ID: 2, Price: 20}},
+		Refs:      []*diffItem{{ID: 1, Price: 1}},
+		Meta:      map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 2}},
+		UpdatedAt: at,
+		Any:       1,
+		internal:  "a",
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		// Equal times in different locations are not changed
+		same := old
+		same.UpdatedAt = at.In(time.FixedZone("Tehran", 3*3600))
+		same.Version = 2
+		same.internal = "b"
+
+		changes, err := DiffStructs(old, &same)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+	})
+	t.Run("changes", func(t *testing.T) {
+		updated := diffEntity{
+			DiffAudit: DiffAudit{UpdatedBy: "user"},
+			Name:      "new name",
+			Password:  "new",
+			Version:   2,
+			Address:   diffAddress{City: "Shiraz", Zip: "1"},
+			Billing:   &diffAddress{City: "Tabriz"},
+			Tags:      []string{"a", "x"},
+			Items:     []diffItem{{ID: 3, Price: 30}, {ID: 2, Price: 25}},
+			Refs:      []*diffItem{{ID: 1, Price: 2}},
+			Meta:      map[string]interface{}{"b": map[string]interface{}{"c": 3}, "d": true},
+			UpdatedAt: at.Add(time.Hour),
+			Any:       "1",
+		}
+
+		changes, err := DiffStructs(&old, &updated)
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{
+			{Path: "UpdatedBy", Old: "admin", New: "user"},
+			{Path: "Name", Old: "name", New: "new name"},
+			{Path: "Password", Old: RedactedValue, New: RedactedValue},
+			{Path: "Address.City", Old: "Tehran", New: "Shiraz"},
+			{Path: "Billing", Old: (*diffAddress)(nil), New: &diffAddress{City: "Tabriz"}},
+			{Path: "Tags[1]", Old: "b", New: "x"},
+			{Path: "Tags[2]", Old: "c"},
+			{Path: "Items[ID=1]", Old: diffItem{ID: 1, Price: 10}},
+			{Path: "Items[ID=2].Price", Old: 20.0, New: 25.0},
+			{Path: "Items[ID=3]", New: diffItem{ID: 3, Price: 30}},
+			{Path: "Refs[ID=1].Price", Old: 1.0, New: 2.0},
+			{Path: "Meta[a]", Old: 1},
+			{Path: "Meta[b][c]", Old: 2, New: 3},
+			{Path: "Meta[d]", New: true},
+			{Path: "UpdatedAt", Old: at, New: at.Add(time.Hour)},
+			{Path: "Any", Old: 1, New: "1"},
+		}, changes)
+	})
+	t.Run("nil pointer", func(t *testing.T) {
+		changes, err := DiffStructs((*diffAddress)(nil), &diffAddress{City: "Tehran"})
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{{Path: "City", Old: "", New: "Tehran"}}, changes)
+	})
+	t.Run("unexported embedded pointer", func(t *testing.T) {
+		changes, err := DiffStructs(diffOuter{X: 1}, diffOuter{diffInner: &diffInner{A: 1}, X: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{{Path: "A", Old: 0, New: 1}}, changes)
+
+		changes, err = DiffStructs(diffOuter{diffInner: &diffInner{A: 1}}, diffOuter{X: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{{Path: "A", Old: 1, New: 0}, {Path: "X", Old: 0, New: 2}}, changes)
+	})
+	t.Run("unexported values", func(t *testing.T) {
+		changes, err := DiffStructs(diffOpaque{diffText: diffText{value: "a"}}, diffOpaque{diffText: diffText{value: "b"}, Name: "b"})
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{{Path: "Name", Old: "", New: "b"}}, changes)
+	})
+	t.Run("cycles", func(t *testing.T) {
+		a, b := &cyclicNode{Value: 1}, &cyclicNode{Value: 1}
+		a.Next, b.Next = a, b
+
+		changes, err := DiffStructs(a, b)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+
+		b.Next = &cyclicNode{Value: 2, Next: b}
+		changes, err = DiffStructs(a, b)
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{{Path: "Next.Value", Old: 1, New: 2}}, changes)
+
+		type graph struct {
+			Meta map[string]interface{}
+		}
+
+		am, bm := map[string]interface{}{"n": 1}, map[string]interface{}{"n": 2}
+		am["self"], bm["self"] = am, bm
+
+		changes, err = DiffStructs(graph{Meta: am}, graph{Meta: bm})
+		assert.Nil(t, err)
+		assert.Equal(t, []Change{{Path: "Meta[n]", Old: 1, New: 2}}, changes)
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, err := DiffStructs(old, diffAddress{})
+		assert.EqualError(t, err, "cannot diff nautilus.diffEntity with nautilus.diffAddress")
+
+		_, err = DiffStructs(1, 2)
+		assert.EqualError(t, err, "arguments must be structs or pointers to structs")
+
+		type invalidKey struct {
+			Items []diffItem `diff:"key=Missing"`
+		}
+
+		_, err = DiffStructs(invalidKey{Items: []diffItem{{}}}, invalidKey{})
+		assert.EqualError(t, err, "Items: invalid key field Missing of nautilus.diffItem")
+
+		type malformed struct {
+			Name string `diff:"secret,,"`
+		}
+
+		_, err = DiffStructs(malformed{}, malformed{})
+		assert.EqualError(t, err, `field Name: invalid tag "secret,,": empty option at 7`)
+	})
+}
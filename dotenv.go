@@ -0,0 +1,209 @@
+package nautilus
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var dotEnvKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// LoadDotEnv reads and parses the .env file in given path
+func LoadDotEnv(path string) (MapSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseDotEnv(file)
+}
+
+// ParseDotEnv parses content of a .env file. Each line contains a
+// KEY=value pair which could be prefixed with `export`. Lines starting
+// with # are comments and unquoted values could have inline comments.
+// Single quoted values are taken literally, while double quoted values
+// support escape sequences (\n, \t, \", \\ and \$). Quoted values could
+// span multiple lines. Unquoted and double quoted values expand $VAR,
+// ${VAR} and ${VAR:-default} references with keys defined earlier in the
+// file, falling back to process environment.
+func ParseDotEnv(r io.Reader) (MapSource, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDotEnv(string(data), EnvSource())
+}
+
+func parseDotEnv(content string, fallback Source) (MapSource, error) {
+	values := MapSource{}
+	lookup := LayeredSource{values, fallback}
+
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+			line = strings.TrimSpace(line[len("export"):])
+		}
+
+		separator := strings.IndexByte(line, '=')
+		if separator < 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNumber)
+		}
+
+		key := strings.TrimSpace(line[:separator])
+		if !dotEnvKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNumber, key)
+		}
+
+		raw := strings.TrimLeft(line[separator+1:], " \t")
+
+		if raw == "" || (raw[0] != '"' && raw[0] != '\'') {
+			values[key] = expandDotEnvValue(stripInlineComment(raw), lookup, false)
+			continue
+		}
+
+		quote := raw[0]
+		content := raw[1:]
+		for {
+			end := closingQuoteIndex(content, quote)
+			if end >= 0 {
+				trailing := strings.TrimSpace(content[end+1:])
+				if trailing != "" && !strings.HasPrefix(trailing, "#") {
+					return nil, fmt.Errorf("line %d: unexpected characters after quoted value", lineNumber)
+				}
+
+				content = content[:end]
+				break
+			}
+
+			if i+1 >= len(lines) {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", lineNumber)
+			}
+
+			i++
+			content += "\n" + lines[i]
+		}
+
+		if quote == '"' {
+			values[key] = expandDotEnvValue(content, lookup, true)
+		} else {
+			values[key] = content
+		}
+	}
+
+	return values, nil
+}
+
+// stripInlineComment removes comments that start with a whitespace and #
+func stripInlineComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// closingQuoteIndex finds index of the closing quote, skipping escaped
+// quotes in double quoted values
+func closingQuoteIndex(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+
+		if s[i] == quote {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// expandDotEnvValue expands variable references of given value and if
+// escapes is true, replaces its escape sequences
+func expandDotEnvValue(value string, lookup Source, escapes bool) string {
+	var result strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		if escapes && c == '\\' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n':
+				result.WriteByte('\n')
+			case 't':
+				result.WriteByte('\t')
+			case 'r':
+				result.WriteByte('\r')
+			case '"', '\\', '$':
+				result.WriteByte(value[i])
+			default:
+				result.WriteByte('\\')
+				result.WriteByte(value[i])
+			}
+
+			continue
+		}
+
+		if c != '$' || i+1 >= len(value) {
+			result.WriteByte(c)
+			continue
+		}
+
+		if value[i+1] == '{' {
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				result.WriteByte(c)
+				continue
+			}
+
+			name := value[i+2 : i+end]
+			fallback := ""
+			if index := strings.Index(name, ":-"); index >= 0 {
+				name, fallback = name[:index], name[index+2:]
+			}
+
+			result.WriteString(GetEnvFrom(lookup, name, fallback))
+			i += end
+			continue
+		}
+
+		end := i + 1
+		for end < len(value) && isDotEnvNameChar(value[end], end == i+1) {
+			end++
+		}
+
+		if end == i+1 {
+			result.WriteByte(c)
+			continue
+		}
+
+		result.WriteString(GetEnvFrom(lookup, value[i+1:end], ""))
+		i = end - 1
+	}
+
+	return result.String()
+}
+
+func isDotEnvNameChar(c byte, first bool) bool {
+	if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+
+	return !first && c >= '0' && c <= '9'
+}
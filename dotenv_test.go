@@ -0,0 +1,62 @@
+package nautilus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dotEnvContent = `
+# Application settings
+APP_NAME=nautilus
+export APP_ENV = production # inline comment
+APP_URL=http://${APP_HOST:-localhost}:$APP_PORT/api#fragment
+APP_PORT=8080
+APP_ADDR=${APP_NAME}:${APP_PORT}
+SINGLE='literal ${APP_NAME}\n'
+DOUBLE="line\tone\n\"quoted\" \$APP_NAME ${APP_NAME}"
+MULTI="first line
+second line"
+MULTI_SINGLE='first
+second'
+EMPTY=
+FROM_PROCESS=${DOTENV_TEST_PROCESS}
+`
+
+func TestParseDotEnv(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer setEnvs(map[string]string{"DOTENV_TEST_PROCESS": "process"})()
+
+		values, err := ParseDotEnv(strings.NewReader(dotEnvContent))
+
+		assert.Nil(t, err)
+		assert.Equal(t, MapSource{
+			"APP_NAME":     "nautilus",
+			"APP_ENV":      "production",
+			"APP_URL":      "http://localhost:/api#fragment",
+			"APP_PORT":     "8080",
+			"APP_ADDR":     "nautilus:8080",
+			"SINGLE":       `literal ${APP_NAME}\n`,
+			"DOUBLE":       "line\tone\n\"quoted\" $APP_NAME nautilus",
+			"MULTI":        "first line\nsecond line",
+			"MULTI_SINGLE": "first\nsecond",
+			"EMPTY":        "",
+			"FROM_PROCESS": "process",
+		}, values)
+	})
+	t.Run("errors", func(t *testing.T) {
+		invalid := map[string]string{
+			"KEY":                          "line 1: expected KEY=value",
+			"1KEY=value":                   `line 1: invalid key "1KEY"`,
+			"\nKEY=\"value":                "line 2: unterminated quoted value",
+			"KEY='value' end":              "line 1: unexpected characters after quoted value",
+			"A=1\nKEY=\"multi\nline\" end": "line 2: unexpected characters after quoted value",
+		}
+
+		for content, message := range invalid {
+			_, err := ParseDotEnv(strings.NewReader(content))
+			assert.EqualError(t, err, message)
+		}
+	})
+}
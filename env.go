@@ -0,0 +1,265 @@
+package nautilus
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	netURL "net/url"
+	"reflect"
+	"strings"
+
+	"github.com/Kamva/nautilus/url"
+)
+
+// ErrEnvNotSet is the error of required environment variables that are
+// not set and have no default value
+var ErrEnvNotSet = errors.New("required environment variable is not set")
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	urlType             = reflect.TypeOf(url.URL{})
+	netURLType          = reflect.TypeOf(netURL.URL{})
+)
+
+// EnvError is the error of a missing or invalid environment variable
+type EnvError struct {
+	Key   string
+	Field string
+	Err   error
+}
+
+// Error implements error interface
+func (e *EnvError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Key, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *EnvError) Unwrap() error {
+	return e.Err
+}
+
+// EnvErrors contains all errors occurred while loading environment
+// variables into a struct
+type EnvErrors []*EnvError
+
+// Error implements error interface
+func (e EnvErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// LoadEnv populates fields of the struct that v points to with environment
+// variables. Variable name of each field is read from `env` tag and fields
+// without this tag are skipped. Fields could have `default` tag which is
+// used when variable is not set, `required:"true"` tag which fails the
+// loading when variable is not set, and `envSeparator` tag for slice and
+// map fields which defaults to comma. Nested structs are populated
+// recursively and their variable names are prefixed with `envPrefix` tag.
+//
+// Supported field types are strings, booleans, numbers, time.Duration,
+// URLs, slices, maps (in "key:value,key:value" format), pointers to them
+// and types implementing encoding.TextUnmarshaler. Similar to GetEnv, an
+// empty variable is considered not set.
+//
+// All missing and invalid variables are returned together as EnvErrors.
+func LoadEnv(v interface{}) error {
+	return LoadEnvWithPrefix("", v)
+}
+
+// LoadEnvWithPrefix is like LoadEnv but prefixes all variable names with
+// given prefix
+func LoadEnvWithPrefix(prefix string, v interface{}) error {
+	return loadEnv(EnvSource(), prefix, v)
+}
+
+func loadEnv(source Source, prefix string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("input must be a non-nil pointer to a struct")
+	}
+
+	var errs EnvErrors
+	if err := loadEnvStruct(source, prefix, rv.Elem(), "", &errs); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func loadEnvStruct(source Source, prefix string, v reflect.Value, path string, errs *EnvErrors) error {
+	fieldsData, err := structFieldsMetadata(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for i, fieldData := range fieldsData {
+		if !fieldData.Exported {
+			continue
+		}
+
+		field := v.Field(i)
+		fieldPath := joinFieldPath(path, fieldData.Name)
+		key, hasKey := fieldData.Tags.Lookup("env")
+
+		if !hasKey && isNestedStruct(fieldData.Type) {
+			target := field
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					field.Set(reflect.New(field.Type().Elem()))
+				}
+
+				target = field.Elem()
+			}
+
+			nestedPrefix := prefix + fieldData.Tags.Get("envPrefix")
+			if err := loadEnvStruct(source, nestedPrefix, target, fieldPath, errs); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !hasKey || key == "-" {
+			continue
+		}
+
+		key = prefix + key
+		value, ok := source.Lookup(key)
+		if !ok || value == "" {
+			value, ok = fieldData.Tags.Lookup("default")
+		}
+
+		if !ok {
+			if fieldData.Tags.Get("required") == "true" {
+				*errs = append(*errs, &EnvError{Key: key, Field: fieldPath, Err: ErrEnvNotSet})
+			}
+
+			continue
+		}
+
+		separator := fieldData.Tags.Get("envSeparator")
+		if err := setFromString(field, value, separator); err != nil {
+			*errs = append(*errs, &EnvError{Key: key, Field: fieldPath, Err: err})
+		}
+	}
+
+	return nil
+}
+
+// isNestedStruct checks whether fields of given type (or pointer type)
+// should be processed recursively, instead of treating it as a single
+// value like URLs and text unmarshalers (e.g. time.Time)
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == urlType || t == netURLType {
+		return false
+	}
+
+	return !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// setFromString parses given string into given value based on value type.
+// Slices and maps elements are split by given separator (comma by default)
+// and map keys and values are separated by colon.
+func setFromString(v reflect.Value, s string, separator string) error {
+	if separator == "" {
+		separator = ","
+	}
+
+	t := v.Type()
+
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) && t.Kind() != reflect.Ptr {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch {
+	case t.Kind() == reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := setFromString(elem.Elem(), s, separator); err != nil {
+			return err
+		}
+
+		v.Set(elem)
+	case t == urlType:
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(*u))
+	case t == netURLType:
+		u, err := netURL.Parse(s)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(*u))
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		parts := splitList(s, separator)
+		slice := reflect.MakeSlice(t, len(parts), len(parts))
+		for i, part := range parts {
+			if err := setFromString(slice.Index(i), part, separator); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+	case t.Kind() == reflect.Map:
+		parts := splitList(s, separator)
+		m := reflect.MakeMapWithSize(t, len(parts))
+		for _, part := range parts {
+			pair := strings.SplitN(part, ":", 2)
+			if len(pair) != 2 {
+				return fmt.Errorf("invalid map item %q, expected key:value", part)
+			}
+
+			key := reflect.New(t.Key()).Elem()
+			if err := setFromString(key, strings.TrimSpace(pair[0]), separator); err != nil {
+				return err
+			}
+
+			elem := reflect.New(t.Elem()).Elem()
+			if err := setFromString(elem, strings.TrimSpace(pair[1]), separator); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(key, elem)
+		}
+
+		v.Set(m)
+	default:
+		value, err := convertValue(reflect.ValueOf(s), t, true)
+		if err != nil {
+			return err
+		}
+
+		v.Set(value)
+	}
+
+	return nil
+}
+
+// splitList splits given string by separator, trimming spaces around items
+func splitList(s string, separator string) []string {
+	if strings.TrimSpace(s) == "" {
+		return []string{}
+	}
+
+	parts := strings.Split(s, separator)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return parts
+}
@@ -0,0 +1,133 @@
+package nautilus
+
+import (
+	"errors"
+	netURL "net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Kamva/nautilus/url"
+	"github.com/stretchr/testify/assert"
+)
+
+type envDatabase struct {
+	Host string `env:"HOST" default:"localhost"`
+	Port int    `env:"PORT" default:"5432"`
+	User string `env:"USER" required:"true"`
+}
+
+type envConfig struct {
+	Name     string            `env:"APP_NAME" required:"true"`
+	Debug    bool              `env:"APP_DEBUG"`
+	Timeout  time.Duration     `env:"APP_TIMEOUT" default:"5s"`
+	Ratio    *float64          `env:"APP_RATIO"`
+	Hosts    []string          `env:"APP_HOSTS"`
+	Ports    []int             `env:"APP_PORTS" envSeparator:";"`
+	Limits   map[string]int    `env:"APP_LIMITS"`
+	Callback *url.URL          `env:"APP_CALLBACK"`
+	Endpoint netURL.URL        `env:"APP_ENDPOINT"`
+	Started  time.Time         `env:"APP_STARTED"`
+	Database envDatabase       `envPrefix:"DB_"`
+	Replica  *envDatabase      `envPrefix:"REPLICA_"`
+	Ignored  string            `env:"-"`
+	Labels   map[string]string `env:"APP_LABELS"`
+	NoTag    string
+}
+
+func setEnvs(envs map[string]string) func() {
+	for key, value := range envs {
+		_ = os.Setenv(key, value)
+	}
+
+	return func() {
+		for key := range envs {
+			_ = os.Unsetenv(key)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer setEnvs(map[string]string{
+			"APP_NAME":     "nautilus",
+			"APP_DEBUG":    "true",
+			"APP_RATIO":    "0.5",
+			"APP_HOSTS":    "a.com, b.com",
+			"APP_PORTS":    "080;0x1bb",
+			"APP_LIMITS":   "read:010,write:0b101",
+			"APP_CALLBACK": "https://www.example.com/callback",
+			"APP_ENDPOINT": "http://localhost:8080/api",
+			"APP_STARTED":  "2020-01-02T03:04:05Z",
+			"DB_USER":      "admin",
+			"DB_PORT":      "",
+			"REPLICA_HOST": "replica",
+			"REPLICA_USER": "reader",
+			"NoTag":        "value",
+		})()
+
+		var c envConfig
+		err := LoadEnv(&c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "nautilus", c.Name)
+		assert.True(t, c.Debug)
+		assert.Equal(t, 5*time.Second, c.Timeout)
+		assert.Equal(t, 0.5, *c.Ratio)
+		assert.Equal(t, []string{"a.com", "b.com"}, c.Hosts)
+		assert.Equal(t, []int{80, 443}, c.Ports)
+		assert.Equal(t, map[string]int{"read": 10, "write": 5}, c.Limits)
+		assert.Equal(t, "example.com", c.Callback.GetDomain())
+		assert.Equal(t, "localhost:8080", c.Endpoint.Host)
+		assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), c.Started)
+		assert.Equal(t, envDatabase{Host: "localhost", Port: 5432, User: "admin"}, c.Database)
+		assert.Equal(t, &envDatabase{Host: "replica", Port: 5432, User: "reader"}, c.Replica)
+		assert.Equal(t, "", c.NoTag)
+		assert.Nil(t, c.Labels)
+	})
+	t.Run("errors", func(t *testing.T) {
+		defer setEnvs(map[string]string{
+			"APP_DEBUG":   "maybe",
+			"APP_PORTS":   "80;http",
+			"APP_LIMITS":  "read",
+			"DB_PORT":     "5432a",
+			"APP_TIMEOUT": "soon",
+		})()
+
+		var c envConfig
+		err := LoadEnv(&c)
+
+		errs, ok := err.(EnvErrors)
+		assert.True(t, ok)
+
+		keys := make(map[string]string)
+		for _, e := range errs {
+			keys[e.Key] = e.Field
+		}
+
+		assert.Equal(t, map[string]string{
+			"APP_NAME":     "Name",
+			"APP_DEBUG":    "Debug",
+			"APP_TIMEOUT":  "Timeout",
+			"APP_PORTS":    "Ports",
+			"APP_LIMITS":   "Limits",
+			"DB_PORT":      "Database.Port",
+			"DB_USER":      "Database.User",
+			"REPLICA_USER": "Replica.User",
+		}, keys)
+		assert.True(t, errors.Is(errs[0], ErrEnvNotSet))
+	})
+	t.Run("prefix", func(t *testing.T) {
+		defer setEnvs(map[string]string{"TEST_HOST": "db", "TEST_USER": "root"})()
+
+		var d envDatabase
+		err := LoadEnvWithPrefix("TEST_", &d)
+
+		assert.Nil(t, err)
+		assert.Equal(t, envDatabase{Host: "db", Port: 5432, User: "root"}, d)
+	})
+	t.Run("invalid input", func(t *testing.T) {
+		assert.NotNil(t, LoadEnv(envDatabase{}))
+		assert.NotNil(t, LoadEnv((*envDatabase)(nil)))
+	})
+}
@@ -0,0 +1,339 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is a single part of a field path, which is either a field
+// name (or a map key after a dot) or the content of brackets which is an
+// index of slice or array, or a map key
+type pathSegment struct {
+	name    string
+	bracket bool
+}
+
+// parseFieldPath parses field path expressions such as
+// "Customer.Address.City", "Items[2].Price" or `Meta["key"].Value`
+func parseFieldPath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, errors.New("field path is empty")
+	}
+
+	var segments []pathSegment
+	expectName := true
+
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '[':
+			end, key, err := parseBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+
+			segments = append(segments, pathSegment{name: key, bracket: true})
+			expectName = false
+			i = end + 1
+		case path[i] == '.':
+			if expectName {
+				return nil, fmt.Errorf("invalid field path %q: unexpected '.' at %d", path, i)
+			}
+
+			expectName = true
+			i++
+		default:
+			if !expectName {
+				return nil, fmt.Errorf("invalid field path %q: expected '.' or '[' at %d", path, i)
+			}
+
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' && path[end] != ']' {
+				end++
+			}
+
+			if end < len(path) && path[end] == ']' {
+				return nil, fmt.Errorf("invalid field path %q: unexpected ']' at %d", path, end)
+			}
+
+			segments = append(segments, pathSegment{name: path[i:end]})
+			expectName = false
+			i = end
+		}
+	}
+
+	if expectName {
+		return nil, fmt.Errorf("invalid field path %q: path ends with '.'", path)
+	}
+
+	return segments, nil
+}
+
+// parseBracket parses the bracket starting at given position, returning
+// the position of closing bracket and its content. Quoted contents could
+// contain brackets and escaped characters.
+func parseBracket(path string, start int) (int, string, error) {
+	if start+1 < len(path) && path[start+1] == '"' {
+		for end := start + 2; end < len(path); end++ {
+			if path[end] == '\\' {
+				end++
+				continue
+			}
+
+			if path[end] == '"' {
+				if end+1 >= len(path) || path[end+1] != ']' {
+					return 0, "", fmt.Errorf("invalid field path %q: expected ']' at %d", path, end+1)
+				}
+
+				key, err := strconv.Unquote(path[start+1 : end+1])
+				if err != nil {
+					return 0, "", fmt.Errorf("invalid field path %q: %v", path, err)
+				}
+
+				return end + 1, key, nil
+			}
+		}
+
+		return 0, "", fmt.Errorf("invalid field path %q: unterminated quoted key", path)
+	}
+
+	end := strings.IndexByte(path[start:], ']')
+	if end < 0 {
+		return 0, "", fmt.Errorf("invalid field path %q: unterminated '['", path)
+	}
+
+	return start + end, path[start+1 : start+end], nil
+}
+
+// formatFieldPath converts segments back to a path expression
+func formatFieldPath(segments []pathSegment) string {
+	var builder strings.Builder
+	for i, segment := range segments {
+		switch {
+		case segment.bracket:
+			builder.WriteString("[" + segment.name + "]")
+		case i > 0:
+			builder.WriteString("." + segment.name)
+		default:
+			builder.WriteString(segment.name)
+		}
+	}
+
+	return builder.String()
+}
+
+// fieldPathWalker resolves a parsed field path on a value and runs op on
+// the resolved value
+type fieldPathWalker struct {
+	segments []pathSegment
+
+	// alloc enables allocating nil pointers and maps along the path
+	alloc bool
+
+	// dryRun resolves the path like alloc, but walks zero values instead
+	// of allocating anything, so the path could be validated before
+	// modifying the original value
+	dryRun bool
+
+	// addressable requires the resolved value to be addressable inside the
+	// original value, so values that are copied out of maps are rejected
+	addressable bool
+
+	op func(v reflect.Value) error
+}
+
+func (w *fieldPathWalker) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("field %s: %s", formatFieldPath(w.segments[:pos]), fmt.Sprintf(format, args...))
+}
+
+// walk resolves the segments starting from pos on v. detached reports that
+// v is a copy of a map element, so changes to it must be written back.
+func (w *fieldPathWalker) walk(v reflect.Value, pos int, detached bool) error {
+	if pos == len(w.segments) {
+		if w.addressable && (detached || !v.CanAddr()) {
+			return w.errorf(pos, "value is not addressable")
+		}
+
+		return w.op(v)
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if v.Kind() == reflect.Interface {
+				return w.errorf(pos, "nil interface")
+			}
+
+			if !w.alloc && !w.dryRun {
+				return w.errorf(pos, "nil pointer")
+			}
+
+			if !v.CanSet() {
+				return w.errorf(pos, "cannot allocate nil pointer")
+			}
+
+			if w.dryRun {
+				v = reflect.New(v.Type().Elem())
+			} else {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+		}
+
+		if v.Kind() == reflect.Ptr {
+			detached = false
+		}
+
+		v = v.Elem()
+	}
+
+	segment := w.segments[pos]
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if segment.bracket {
+			return w.errorf(pos+1, "cannot index struct %s", v.Type())
+		}
+
+		field, err := w.structField(v, pos)
+		if err != nil {
+			return err
+		}
+
+		return w.walk(field, pos+1, detached)
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(segment.name)
+		if err != nil {
+			return w.errorf(pos+1, "invalid index %q", segment.name)
+		}
+
+		if index < 0 || index >= v.Len() {
+			return w.errorf(pos+1, "index %d out of range with length %d", index, v.Len())
+		}
+
+		return w.walk(v.Index(index), pos+1, detached)
+	case reflect.Map:
+		return w.walkMap(v, pos, detached)
+	default:
+		return w.errorf(pos+1, "cannot resolve %q on %s", segment.name, v.Type())
+	}
+}
+
+// structField returns the field of segment at given position, allocating
+// nil embedded pointers of promoted fields if enabled
+func (w *fieldPathWalker) structField(v reflect.Value, pos int) (reflect.Value, error) {
+	field, ok := getStructMetadata(v.Type()).fieldByName(w.segments[pos].name)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("field %s not found", formatFieldPath(w.segments[:pos+1]))
+	}
+
+	if field.PkgPath != "" {
+		return reflect.Value{}, fmt.Errorf("field %s is not exported", formatFieldPath(w.segments[:pos+1]))
+	}
+
+	for depth, index := range field.Index {
+		if depth > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !(w.alloc || w.dryRun) || !v.CanSet() {
+					return reflect.Value{}, w.errorf(pos+1, "promoted through a nil embedded pointer")
+				}
+
+				if w.dryRun {
+					v = reflect.New(v.Type().Elem())
+				} else {
+					v.Set(reflect.New(v.Type().Elem()))
+				}
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(index)
+	}
+
+	return v, nil
+}
+
+// walkMap resolves the rest of the path on a copy of the map element and
+// stores the copy back into the map, if the walker is allowed to modify
+func (w *fieldPathWalker) walkMap(v reflect.Value, pos int, detached bool) error {
+	key, err := convertValue(reflect.ValueOf(w.segments[pos].name), v.Type().Key(), true)
+	if err != nil {
+		return w.errorf(pos+1, "invalid map key: %v", err)
+	}
+
+	elem := v.MapIndex(key)
+	if !elem.IsValid() && !w.alloc && !w.dryRun {
+		return w.errorf(pos+1, "key %q not found", w.segments[pos].name)
+	}
+
+	value := reflect.New(v.Type().Elem()).Elem()
+	if elem.IsValid() {
+		value.Set(elem)
+	}
+
+	if err := w.walk(value, pos+1, true); err != nil {
+		return err
+	}
+
+	if w.dryRun {
+		if v.IsNil() && !v.CanSet() {
+			return w.errorf(pos, "cannot allocate nil map")
+		}
+
+		return nil
+	}
+
+	if !w.alloc {
+		return nil
+	}
+
+	if v.IsNil() {
+		if !v.CanSet() {
+			return w.errorf(pos, "cannot allocate nil map")
+		}
+
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+
+	v.SetMapIndex(key, value)
+	return nil
+}
+
+// resolveFieldPath parses given path and runs op on the resolved value of
+// the struct that i points to. If alloc is true, the path is resolved in a
+// dry run first and validate is called with a zero value of the resolved
+// type, so nothing is allocated unless the whole path resolves and
+// validate succeeds.
+func resolveFieldPath(i interface{}, path string, alloc bool, addressable bool, op func(v reflect.Value) error, validate ...func(v reflect.Value) error) error {
+	v := reflect.ValueOf(i)
+
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("input must be a non-nil pointer to a struct")
+	}
+
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return err
+	}
+
+	if alloc {
+		dryRun := &fieldPathWalker{segments: segments, dryRun: true, addressable: addressable, op: func(v reflect.Value) error {
+			for _, fn := range validate {
+				if err := fn(reflect.New(v.Type()).Elem()); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}}
+
+		if err := dryRun.walk(v, 0, false); err != nil {
+			return err
+		}
+	}
+
+	walker := &fieldPathWalker{segments: segments, alloc: alloc, addressable: addressable, op: op}
+
+	return walker.walk(v, 0, false)
+}
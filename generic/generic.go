@@ -0,0 +1,112 @@
+// Package generic contains type-safe variants of nautilus helpers, which
+// return typed values instead of interface{} and share the implementation
+// of their nautilus counterparts.
+package generic
+
+import (
+	"fmt"
+
+	"github.com/Kamva/nautilus"
+	"github.com/Kamva/nautilus/types"
+)
+
+// Ptr returns a pointer to a copy of given value. It is the typed variant
+// of nautilus.ValueToPointer, e.g. Ptr("name") is a *string.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value that p points to, or the zero value of T if p is
+// nil. It is the typed variant of nautilus.PointerToValue.
+func Deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+
+	return *p
+}
+
+// DerefOr is like Deref, but returns given fallback if p is nil
+func DerefOr[T any](p *T, fallback T) T {
+	if p == nil {
+		return fallback
+	}
+
+	return *p
+}
+
+// IsZero is the typed variant of nautilus.IsZero
+func IsZero[T any](v T) bool {
+	return nautilus.IsZero(v)
+}
+
+// IsEmpty is the typed variant of nautilus.IsEmpty
+func IsEmpty[T any](v T) bool {
+	return nautilus.IsEmpty(v)
+}
+
+// FieldPtr returns a typed pointer to the field of the struct that s points
+// to, which could be a path expression supported by nautilus.GetFieldPointer.
+// It returns an error if the field is not of type T.
+func FieldPtr[T any](s interface{}, name string) (*T, error) {
+	ptr, err := nautilus.GetFieldPointer(s, name)
+	if err != nil {
+		return nil, err
+	}
+
+	typed, ok := ptr.(*T)
+	if !ok {
+		return nil, fmt.Errorf("field %s is of type %T, not %T", name, ptr, typed)
+	}
+
+	return typed, nil
+}
+
+// Field returns the value of the field of struct s converted to T. Field
+// name could be a path expression supported by nautilus.GetFieldValue and
+// values are converted like nautilus.ConvertValue.
+func Field[T any](s interface{}, name string) (T, error) {
+	var result T
+
+	value, err := nautilus.GetFieldValue(s, name)
+	if err != nil {
+		return result, err
+	}
+
+	if err := nautilus.ConvertValue(value, &result); err != nil {
+		return result, fmt.Errorf("field %s: %v", name, err)
+	}
+
+	return result, nil
+}
+
+// Get returns the value in given path of the map, as described in
+// types.JSONMap.Get, converted to T. Values are converted like
+// nautilus.ConvertValue, so numbers decoded from JSON (which are float64)
+// could be read as int if they are whole numbers.
+func Get[T any](m types.JSONMap, path string) (T, error) {
+	var result T
+
+	value, ok := m.Get(path)
+	if !ok {
+		return result, fmt.Errorf("path %q not found", path)
+	}
+
+	if err := nautilus.ConvertValue(value, &result); err != nil {
+		return result, fmt.Errorf("path %q: %v", path, err)
+	}
+
+	return result, nil
+}
+
+// GetOr is like Get, but returns given fallback if the path does not exist
+// or its value could not be converted to T
+func GetOr[T any](m types.JSONMap, path string, fallback T) T {
+	result, err := Get[T](m, path)
+	if err != nil {
+		return fallback
+	}
+
+	return result
+}
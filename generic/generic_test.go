@@ -0,0 +1,158 @@
+package generic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kamva/nautilus"
+	"github.com/Kamva/nautilus/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPtrAndDeref(t *testing.T) {
+	p := Ptr("name")
+	assert.Equal(t, "name", *p)
+	assert.Equal(t, "name", Deref(p))
+	assert.Equal(t, "", Deref((*string)(nil)))
+	assert.Equal(t, 0, Deref((*int)(nil)))
+
+	assert.Equal(t, 1, DerefOr(Ptr(1), 2))
+	assert.Equal(t, 2, DerefOr(nil, 2))
+}
+
+func TestIsZero(t *testing.T) {
+	assert.True(t, IsZero(0))
+	assert.True(t, IsZero(""))
+	assert.True(t, IsZero((*int)(nil)))
+	assert.True(t, IsZero(time.Time{}))
+	assert.True(t, IsZero(time.Time{}.In(time.UTC)))
+	assert.True(t, IsZero(struct{ A int }{}))
+
+	assert.False(t, IsZero(1))
+	assert.False(t, IsZero(Ptr(0)))
+	assert.False(t, IsZero(time.Now()))
+	assert.False(t, IsZero(struct{ A int }{A: 1}))
+	assert.True(t, IsZero([]int(nil)))
+	assert.False(t, IsZero([]int{}))
+
+	// Results are the same as nautilus.IsZero
+	assert.True(t, IsZero((*time.Time)(nil)))
+	assert.False(t, IsZero(&time.Time{}))
+	assert.True(t, IsZero(ptrZeroer{Value: 1}))
+	assert.Equal(t, nautilus.IsZero((*time.Time)(nil)), IsZero((*time.Time)(nil)))
+	assert.Equal(t, nautilus.IsZero(&time.Time{}), IsZero(&time.Time{}))
+	assert.Equal(t, nautilus.IsZero(ptrZeroer{Value: 1}), IsZero(ptrZeroer{Value: 1}))
+}
+
+// ptrZeroer is always zero by its IsZero method, which has a pointer
+// receiver
+type ptrZeroer struct {
+	Value int
+}
+
+func (z *ptrZeroer) IsZero() bool {
+	return true
+}
+
+func TestIsEmpty(t *testing.T) {
+	assert.True(t, IsEmpty([]int{}))
+	assert.True(t, IsEmpty(map[string]int(nil)))
+	assert.True(t, IsEmpty(Ptr("")))
+	assert.False(t, IsEmpty([]int{0}))
+}
+
+type address struct {
+	City string
+}
+
+type user struct {
+	Name    string
+	Age     int
+	Address *address
+	Tags    []string
+}
+
+func TestFieldPtr(t *testing.T) {
+	u := &user{Tags: []string{"a"}}
+
+	name, err := FieldPtr[string](u, "Name")
+	assert.Nil(t, err)
+	*name = "john"
+	assert.Equal(t, "john", u.Name)
+
+	_, err = FieldPtr[string](u, "Address.City")
+	assert.EqualError(t, err, "field Address: nil pointer")
+	assert.Nil(t, u.Address)
+
+	u.Address = &address{}
+	city, err := FieldPtr[string](u, "Address.City")
+	assert.Nil(t, err)
+	*city = "Tehran"
+	assert.Equal(t, "Tehran", u.Address.City)
+
+	_, err = FieldPtr[int](u, "Name")
+	assert.EqualError(t, err, "field Name is of type *string, not *int")
+
+	_, err = FieldPtr[int](u, "Missing")
+	assert.EqualError(t, err, "field Missing not found")
+}
+
+func TestField(t *testing.T) {
+	u := user{Name: "john", Age: 30, Tags: []string{"a", "b"}}
+
+	name, err := Field[string](u, "Name")
+	assert.Nil(t, err)
+	assert.Equal(t, "john", name)
+
+	age, err := Field[int64](&u, "Age")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), age)
+
+	tag, err := Field[string](u, "Tags[1]")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", tag)
+
+	_, err = Field[string](u, "Address.City")
+	assert.EqualError(t, err, "field Address: nil pointer")
+
+	_, err = Field[int](u, "Name")
+	assert.EqualError(t, err, `field Name: cannot convert string to int`)
+}
+
+func TestGet(t *testing.T) {
+	m := types.JSONMap{
+		"user": map[string]interface{}{
+			"name":   "john",
+			"age":    float64(30),
+			"score":  1.5,
+			"tags":   []interface{}{"a"},
+			"active": true,
+		},
+	}
+
+	name, err := Get[string](m, "user.name")
+	assert.Nil(t, err)
+	assert.Equal(t, "john", name)
+
+	age, err := Get[int](m, "user.age")
+	assert.Nil(t, err)
+	assert.Equal(t, 30, age)
+
+	tag, err := Get[string](m, "user.tags[0]")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", tag)
+
+	tags, err := Get[[]interface{}](m, "user.tags")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a"}, tags)
+
+	_, err = Get[int](m, "user.score")
+	assert.EqualError(t, err, `path "user.score": cannot convert 1.5 to int without losing precision`)
+
+	_, err = Get[string](m, "user.missing")
+	assert.EqualError(t, err, `path "user.missing" not found`)
+
+	assert.True(t, GetOr(m, "user.active", false))
+	assert.Equal(t, 10, GetOr(m, "user.missing", 10))
+	assert.Equal(t, 10, GetOr(m, "user.name", 10))
+}
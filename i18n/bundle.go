@@ -0,0 +1,326 @@
+// Package i18n loads message catalogs of different languages and
+// translates messages with placeholders and plural forms, e.g. to localize
+// error responses of nautilus applications.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CountParam is the name of the parameter which selects the plural form
+// of messages
+const CountParam = "count"
+
+// Options contains options of a Bundle
+type Options struct {
+	// DefaultLanguage is used when a message is not found in requested
+	// languages. Defaults to "en".
+	DefaultLanguage string
+
+	// QueryParam is the query parameter of requests which overrides their
+	// Accept-Language header. Defaults to "lang".
+	QueryParam string
+}
+
+// Message is a translated message, which has different forms for plural
+// categories ("zero", "one", "two", "few", "many" and "other"). Messages
+// without plural forms only have "other" form.
+type Message map[string]string
+
+// Bundle contains message catalogs of languages. It is safe for
+// concurrent use.
+type Bundle struct {
+	options Options
+
+	mutex    sync.RWMutex
+	catalogs map[string]map[string]Message
+}
+
+// New returns a new empty Bundle
+func New(opts ...Options) *Bundle {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.DefaultLanguage == "" {
+		o.DefaultLanguage = "en"
+	}
+
+	if o.QueryParam == "" {
+		o.QueryParam = "lang"
+	}
+
+	o.DefaultLanguage = normalizeLanguage(o.DefaultLanguage)
+
+	return &Bundle{options: o, catalogs: make(map[string]map[string]Message)}
+}
+
+// AddMessages adds messages of given language to the bundle, replacing
+// the existing ones with the same keys. Messages could be strings or maps
+// of plural forms to strings, e.g. {"one": "{count} item", "other":
+// "{count} items"}. Other nested maps are flattened and their keys are
+// joined by dot, e.g. "errors.not_found".
+func (b *Bundle) AddMessages(lang string, messages map[string]interface{}) error {
+	flattened := make(map[string]Message)
+	if err := flattenMessages(messages, "", flattened); err != nil {
+		return err
+	}
+
+	lang = normalizeLanguage(lang)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	catalog, ok := b.catalogs[lang]
+	if !ok {
+		catalog = make(map[string]Message)
+		b.catalogs[lang] = catalog
+	}
+
+	for key, message := range flattened {
+		catalog[key] = message
+	}
+
+	return nil
+}
+
+// LoadFile loads messages of a JSON or YAML file, which its language is
+// the last dot separated part of its name, e.g. "fa.yaml" or
+// "messages.en-US.json"
+func (b *Bundle) LoadFile(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	lang := name[strings.LastIndex(name, ".")+1:]
+
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return fmt.Errorf("unsupported catalog file %s", path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var messages map[string]interface{}
+
+	if ext == ".json" {
+		err = json.Unmarshal(data, &messages)
+	} else {
+		var raw map[interface{}]interface{}
+		err = yaml.Unmarshal(data, &raw)
+		messages = stringKeys(raw)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid catalog file %s: %v", path, err)
+	}
+
+	if err := b.AddMessages(lang, messages); err != nil {
+		return fmt.Errorf("invalid catalog file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// LoadDir loads all JSON and YAML files of given directory by LoadFile
+func (b *Bundle) LoadDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".json", ".yaml", ".yml":
+			if err := b.LoadFile(filepath.Join(dir, file.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Languages returns languages of the bundle in sorted order
+func (b *Bundle) Languages() []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	langs := make([]string, 0, len(b.catalogs))
+	for lang := range b.catalogs {
+		langs = append(langs, lang)
+	}
+
+	sort.Strings(langs)
+	return langs
+}
+
+// Lookup returns the message with given key in the first of given
+// languages that has it, falling back to the default language. Languages
+// with regions (e.g. "fa-IR") fall back to their base language ("fa").
+// Placeholders such as "{name}" are replaced by given params and the
+// plural form of the message is selected by the "count" param.
+func (b *Bundle) Lookup(langs []string, key string, params map[string]interface{}) (string, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	candidates := make([]string, 0, len(langs)+1)
+	candidates = append(candidates, langs...)
+	candidates = append(candidates, b.options.DefaultLanguage)
+
+	for _, lang := range candidates {
+		lang = normalizeLanguage(lang)
+
+		for _, candidate := range []string{lang, baseLanguage(lang)} {
+			if message, ok := b.catalogs[candidate][key]; ok {
+				return format(candidate, message, params), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// T is like Lookup for a single language, but returns the key itself if
+// the message is not found
+func (b *Bundle) T(lang string, key string, params map[string]interface{}) string {
+	if message, ok := b.Lookup([]string{lang}, key, params); ok {
+		return message
+	}
+
+	return key
+}
+
+// format selects the plural form of the message and replaces its
+// placeholders
+func format(lang string, message Message, params map[string]interface{}) string {
+	text, ok := message["other"]
+	if count, hasCount := params[CountParam]; hasCount {
+		if n, err := strconv.ParseFloat(fmt.Sprint(count), 64); err == nil {
+			if form, found := message[PluralCategory(lang, n)]; found {
+				text, ok = form, true
+			}
+		}
+	}
+
+	if !ok {
+		for _, category := range pluralCategories {
+			if text, ok = message[category]; ok {
+				break
+			}
+		}
+	}
+
+	return replacePlaceholders(text, params)
+}
+
+// replacePlaceholders replaces {name} placeholders of given text by the
+// values of params in a single left to right scan, so placeholders inside
+// the inserted values are not replaced. Unknown placeholders are kept.
+func replacePlaceholders(text string, params map[string]interface{}) string {
+	if len(params) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(text, '{')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(text[start+1:], '}')
+		if end < 0 {
+			break
+		}
+
+		end += start + 1
+
+		value, ok := params[text[start+1:end]]
+		if !ok {
+			b.WriteString(text[:start+1])
+			text = text[start+1:]
+			continue
+		}
+
+		b.WriteString(text[:start])
+		b.WriteString(fmt.Sprint(value))
+		text = text[end+1:]
+	}
+
+	b.WriteString(text)
+
+	return b.String()
+}
+
+// flattenMessages adds given messages to result, joining keys of nested
+// maps by dot
+func flattenMessages(messages map[string]interface{}, prefix string, result map[string]Message) error {
+	for key, value := range messages {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case string:
+			result[key] = Message{"other": v}
+		case map[string]interface{}:
+			if message, ok := pluralMessage(v); ok {
+				result[key] = message
+				continue
+			}
+
+			if err := flattenMessages(v, key, result); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message %s must be a string or a map, got %T", key, value)
+		}
+	}
+
+	return nil
+}
+
+// pluralMessage returns given map as a Message if all of its keys are
+// plural categories with string values
+func pluralMessage(m map[string]interface{}) (Message, bool) {
+	if len(m) == 0 {
+		return nil, false
+	}
+
+	message := make(Message, len(m))
+	for key, value := range m {
+		text, ok := value.(string)
+		if !ok || !isPluralCategory(key) {
+			return nil, false
+		}
+
+		message[key] = text
+	}
+
+	return message, true
+}
+
+// stringKeys converts maps decoded from YAML to maps with string keys
+func stringKeys(m map[interface{}]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if nested, ok := value.(map[interface{}]interface{}); ok {
+			value = stringKeys(nested)
+		}
+
+		result[fmt.Sprint(key)] = value
+	}
+
+	return result
+}
@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	dir, err := ioutil.TempDir("", "nautilus-i18n")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	en := `{
+		"greeting": "Hello {name}",
+		"items": {"one": "{count} item", "other": "{count} items"},
+		"errors": {"not_found": "{resource} not found", "internal": "Something went wrong"}
+	}`
+	fa := "greeting: سلام {name}\nitems:\n  other: \"{count} مورد\"\nerrors:\n  not_found: \"{resource} پیدا نشد\"\n"
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "en.json"), []byte(en), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "messages.fa.yaml"), []byte(fa), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0600))
+
+	bundle := New()
+	assert.Nil(t, bundle.LoadDir(dir))
+
+	return bundle
+}
+
+func TestBundle(t *testing.T) {
+	bundle := newTestBundle(t)
+	assert.Equal(t, []string{"en", "fa"}, bundle.Languages())
+
+	t.Run("placeholders", func(t *testing.T) {
+		assert.Equal(t, "Hello John", bundle.T("en", "greeting", map[string]interface{}{"name": "John"}))
+		assert.Equal(t, "سلام علی", bundle.T("fa", "greeting", map[string]interface{}{"name": "علی"}))
+		assert.Equal(t, "user not found", bundle.T("en", "errors.not_found", map[string]interface{}{"resource": "user"}))
+		assert.Equal(t, "Hello {name}", bundle.T("en", "greeting", nil))
+
+		// Inserted values are not replaced again
+		params := map[string]interface{}{"name": "{resource}", "resource": "user"}
+		assert.Equal(t, "Hello {resource}", bundle.T("en", "greeting", params))
+
+		assert.Nil(t, bundle.AddMessages("en", map[string]interface{}{"braces": "{{name}} {unknown} {resource"}))
+		assert.Equal(t, "{John} {unknown} {resource", bundle.T("en", "braces", map[string]interface{}{"name": "John", "resource": "user"}))
+	})
+	t.Run("plural forms", func(t *testing.T) {
+		assert.Equal(t, "1 item", bundle.T("en", "items", map[string]interface{}{"count": 1}))
+		assert.Equal(t, "3 items", bundle.T("en", "items", map[string]interface{}{"count": 3}))
+		assert.Equal(t, "0 items", bundle.T("en", "items", map[string]interface{}{"count": 0.0}))
+		assert.Equal(t, "1 مورد", bundle.T("fa", "items", map[string]interface{}{"count": 1}))
+		assert.Equal(t, "{count} items", bundle.T("en", "items", nil))
+	})
+	t.Run("fallback", func(t *testing.T) {
+		message, ok := bundle.Lookup([]string{"fa-IR"}, "greeting", map[string]interface{}{"name": "علی"})
+		assert.True(t, ok)
+		assert.Equal(t, "سلام علی", message)
+
+		message, ok = bundle.Lookup([]string{"de", "fa"}, "errors.internal", nil)
+		assert.True(t, ok)
+		assert.Equal(t, "Something went wrong", message)
+
+		_, ok = bundle.Lookup([]string{"fa"}, "missing", nil)
+		assert.False(t, ok)
+		assert.Equal(t, "missing", bundle.T("fa", "missing", nil))
+	})
+}
+
+func TestBundle_Errors(t *testing.T) {
+	bundle := New(Options{DefaultLanguage: "fa"})
+
+	assert.EqualError(t, bundle.AddMessages("en", map[string]interface{}{"count": 1}),
+		"message count must be a string or a map, got int")
+	assert.EqualError(t, bundle.LoadFile("en.txt"), "unsupported catalog file en.txt")
+
+	dir, err := ioutil.TempDir("", "nautilus-i18n")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "en.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("{invalid"), 0600))
+	assert.Contains(t, bundle.LoadFile(path).Error(), "invalid catalog file "+path)
+}
+
+func TestPluralCategory(t *testing.T) {
+	assert.Equal(t, "one", PluralCategory("en-US", 1))
+	assert.Equal(t, "other", PluralCategory("en", 1.5))
+	assert.Equal(t, "one", PluralCategory("fa", 0.5))
+	assert.Equal(t, "other", PluralCategory("fa", 2))
+	assert.Equal(t, "other", PluralCategory("de", 2))
+
+	RegisterPluralRule("xx", func(n float64) string { return "many" })
+	assert.Equal(t, "many", PluralCategory("xx", 1))
+}
@@ -0,0 +1,88 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kataras/iris/v12"
+)
+
+// ParseAcceptLanguage returns languages of given Accept-Language header in
+// order of preference, e.g. "fa-IR,fa;q=0.9,en;q=0.8" results in
+// ["fa-ir", "fa", "en"]. Wildcards and languages with zero quality are
+// skipped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang    string
+		quality float64
+	}
+
+	var langs []weighted
+	for _, item := range strings.Split(header, ",") {
+		params := strings.Split(item, ";")
+		lang := normalizeLanguage(params[0])
+		if lang == "" || lang == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			pair := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(pair) == 2 && strings.TrimSpace(pair[0]) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(pair[1]), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > 0 {
+			langs = append(langs, weighted{lang: lang, quality: quality})
+		}
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].quality > langs[j].quality
+	})
+
+	result := make([]string, len(langs))
+	for i := range langs {
+		result[i] = langs[i].lang
+	}
+
+	return result
+}
+
+// RequestLanguages returns the requested languages of the context in
+// order of preference. The query parameter of the bundle (e.g.
+// "?lang=fa") has priority over the Accept-Language header.
+func (b *Bundle) RequestLanguages(ctx iris.Context) []string {
+	langs := ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
+	if lang := normalizeLanguage(ctx.URLParam(b.options.QueryParam)); lang != "" {
+		langs = append([]string{lang}, langs...)
+	}
+
+	return langs
+}
+
+// Translate looks up the message with given key in requested languages of
+// the context, as described in Lookup. It implements nautilus.Translator,
+// so a bundle could localize error responses.
+func (b *Bundle) Translate(ctx iris.Context, key string, params map[string]interface{}) (string, bool) {
+	return b.Lookup(b.RequestLanguages(ctx), key, params)
+}
+
+// normalizeLanguage converts given language tag to lower case and
+// replaces underscores by dashes, e.g. "fa_IR" is converted to "fa-ir"
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+// baseLanguage returns the language of given tag without its region
+func baseLanguage(lang string) string {
+	if i := strings.Index(lang, "-"); i > 0 {
+		return lang[:i]
+	}
+
+	return lang
+}
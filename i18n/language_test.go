@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kamva/nautilus"
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ nautilus.Translator = (*Bundle)(nil)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"fa-ir", "fa", "en"}, ParseAcceptLanguage("fa-IR,fa;q=0.9,en;q=0.8"))
+	assert.Equal(t, []string{"en", "fa"}, ParseAcceptLanguage("fa;q=0.5, en, *;q=0.1, de;q=0"))
+	assert.Empty(t, ParseAcceptLanguage(""))
+}
+
+func TestBundle_Translate(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	app := iris.New()
+	app.OnAnyErrorCode(nautilus.NewErrorRenderer(nautilus.ErrorRendererOptions{Translator: bundle}))
+	app.Get("/users/{id}", nautilus.HandleErrors(func(ctx iris.Context) error {
+		return nautilus.NewAppError(http.StatusNotFound, "errors.not_found", "user not found").
+			WithDetails(map[string]interface{}{"resource": "user"})
+	}))
+	app.Get("/raw", nautilus.HandleErrors(func(ctx iris.Context) error {
+		return nautilus.NewAppError(http.StatusConflict, "duplicate_email", "email is taken")
+	}))
+
+	assert.Nil(t, app.Build())
+
+	serve := func(path string, acceptLanguage string) string {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Accept-Language", acceptLanguage)
+		app.ServeHTTP(recorder, request)
+
+		return recorder.Body.String()
+	}
+
+	assert.JSONEq(t,
+		`{"code":"errors.not_found","message":"user پیدا نشد","details":{"resource":"user"}}`,
+		serve("/users/1", "fa-IR,fa;q=0.9"))
+	assert.JSONEq(t,
+		`{"code":"errors.not_found","message":"user not found","details":{"resource":"user"}}`,
+		serve("/users/1?lang=en", "fa"))
+	assert.JSONEq(t,
+		`{"code":"errors.not_found","message":"user پیدا نشد","details":{"resource":"user"}}`,
+		serve("/users/1?lang=fa", ""))
+	assert.JSONEq(t, `{"code":"duplicate_email","message":"email is taken"}`, serve("/raw", "fa"))
+	assert.JSONEq(t, `{"code":"not_found","message":"Not Found"}`, serve("/missing", "fa"))
+}
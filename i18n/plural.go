@@ -0,0 +1,68 @@
+package i18n
+
+import (
+	"math"
+	"sync"
+)
+
+// PluralRule returns the plural category of number n in a language
+type PluralRule func(n float64) string
+
+// pluralCategories are the CLDR plural categories
+var pluralCategories = []string{"other", "one", "zero", "two", "few", "many"}
+
+var (
+	pluralRulesMutex sync.RWMutex
+	pluralRules      = map[string]PluralRule{
+		"en": func(n float64) string {
+			if n == 1 {
+				return "one"
+			}
+
+			return "other"
+		},
+		// CLDR defines "one" category of Persian for numbers which their
+		// integer part is 0 and for 1
+		"fa": func(n float64) string {
+			if math.Trunc(n) == 0 || n == 1 {
+				return "one"
+			}
+
+			return "other"
+		},
+	}
+)
+
+// RegisterPluralRule registers the plural rule of given language. Languages
+// without plural rules use the rule of English.
+func RegisterPluralRule(lang string, rule PluralRule) {
+	pluralRulesMutex.Lock()
+	defer pluralRulesMutex.Unlock()
+
+	pluralRules[normalizeLanguage(lang)] = rule
+}
+
+// PluralCategory returns the plural category of number n in given language
+func PluralCategory(lang string, n float64) string {
+	pluralRulesMutex.RLock()
+	defer pluralRulesMutex.RUnlock()
+
+	lang = normalizeLanguage(lang)
+	for _, candidate := range []string{lang, baseLanguage(lang), "en"} {
+		if rule, ok := pluralRules[candidate]; ok {
+			return rule(n)
+		}
+	}
+
+	return "other"
+}
+
+func isPluralCategory(s string) bool {
+	for _, category := range pluralCategories {
+		if s == category {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,12 +1,22 @@
 package nautilus
 
-import "reflect"
+import (
+	"errors"
+	"reflect"
+)
 
 // Taggable is an interface for struct that have tagging
 type Taggable interface {
 	GetTag(caller interface{}, field string, tag string) string
 }
 
+// TagsGetter is an interface for struct that could return parsed tags of
+// all of their fields. It is separate from Taggable, so existing
+// implementations of Taggable are not broken.
+type TagsGetter interface {
+	GetTags(caller interface{}, tag string) (map[string]Tag, error)
+}
+
 // BaseTaggable is a base struct for helper for struct needed to implement
 // Taggable interface
 type BaseTaggable struct{}
@@ -19,6 +29,39 @@ func (r BaseTaggable) GetTag(caller interface{}, field string, tag string) strin
 		t = t.Elem()
 	}
 
-	taggedField, _ := t.FieldByName(field)
-	return taggedField.Tag.Get(tag)
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	value, _ := getStructMetadata(t).tag(field, tag)
+	return value
+}
+
+// GetTags returns parsed `tag` values of all fields of `caller` that have
+// the tag, keyed by field name. Promoted fields of embedded structs are
+// included the same way GetTag resolves them. It returns an error if any
+// of the values is malformed.
+func (r BaseTaggable) GetTags(caller interface{}, tag string) (map[string]Tag, error) {
+	t := reflect.TypeOf(caller)
+
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("argument is not a struct")
+	}
+
+	tags, err := getStructMetadata(t).parsedTags(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Tag, len(tags))
+	for field, parsed := range tags {
+		parsed.Options = append([]TagOption(nil), parsed.Options...)
+		result[field] = parsed
+	}
+
+	return result, nil
 }
@@ -0,0 +1,505 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kamva/nautilus/types"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// MapperOptions contains options of struct to JSONMap mapping
+type MapperOptions struct {
+	// TagName is the struct tag that is used for reading key names and
+	// options such as omitempty. Default value is "json".
+	TagName string
+
+	// WeaklyTypedInput enables conversion between strings, numbers and
+	// booleans while mapping JSONMap into struct. For example "42" could
+	// be mapped into an int field and 1 into a bool field.
+	WeaklyTypedInput bool
+
+	// TimeFormat is the layout used for time.Time fields. If it is empty,
+	// ToJSONMap keeps time.Time values as is and FromJSONMap parses string
+	// values in RFC 3339 format.
+	TimeFormat string
+}
+
+// ToJSONMap converts given struct (or pointer to struct) into JSONMap.
+// Keys are read from `json` tag (or TagName option) and fields tagged with
+// "-" are skipped. Fields with omitempty option are skipped if they are
+// empty. Fields of embedded structs are promoted into the parent map,
+// following Go rules for shadowed and ambiguous fields, unless the
+// embedded struct has a name in its tag. Nested structs and maps
+// (including map[string]interface{} values) are converted into nested
+// JSONMap.
+func ToJSONMap(v interface{}, opts ...MapperOptions) (types.JSONMap, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, errors.New("argument is a nil pointer")
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.New("argument is not a struct")
+	}
+
+	return structToJSONMap(rv, mergeMapperOptions(opts))
+}
+
+// FromJSONMap fills the struct that v points to with values of given map.
+// Keys are matched with the same rules of ToJSONMap, falling back to a case
+// insensitive match. Nested maps are mapped into nested structs, nil
+// pointers are allocated and numeric values are converted to field types
+// if they fit without losing precision.
+func FromJSONMap(m types.JSONMap, v interface{}, opts ...MapperOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("output must be a non-nil pointer to a struct")
+	}
+
+	return mapToStruct(m, rv.Elem(), mergeMapperOptions(opts), "")
+}
+
+// mergeMapperOptions returns the first given options filled with defaults
+func mergeMapperOptions(opts []MapperOptions) MapperOptions {
+	o := MapperOptions{}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.TagName == "" {
+		o.TagName = "json"
+	}
+
+	return o
+}
+
+// parseMapperTag returns key name of given field and whether it should be
+// omitted when it is empty. The returned name is "-" for skipped fields.
+func parseMapperTag(fieldData FieldData, tagName string) (name string, omitEmpty bool, named bool) {
+	tag := fieldData.Tags.Get(tagName)
+	if tag == "-" {
+		return "-", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	if parts[0] == "" {
+		return fieldData.Name, omitEmpty, false
+	}
+
+	return parts[0], omitEmpty, true
+}
+
+// embeddedStruct checks if given field is an embedded struct (or pointer
+// to struct) which its fields should be promoted into the parent
+func embeddedStruct(fieldData FieldData, named bool) bool {
+	if !fieldData.Anonymous || named {
+		return false
+	}
+
+	t := fieldData.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct
+}
+
+// mapperField is a field of a struct which is mapped into a map key,
+// including the promoted fields of embedded structs
+type mapperField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// resolveMapperFields returns the fields of given struct type which are
+// mapped into map keys with given tag. Similar to promoted fields of Go,
+// fields of embedded structs are shadowed by the fields with the same key
+// in shallower depths, and the fields which have the same key in the same
+// depth are ambiguous and dropped.
+func resolveMapperFields(t reflect.Type, tagName string) []mapperField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var fields []mapperField
+
+	resolved := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+	current := []embedded{{typ: t}}
+
+	for len(current) > 0 {
+		var next []embedded
+
+		candidates := make(map[string][]mapperField)
+		var names []string
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+
+			visited[e.typ] = true
+
+			for i, fieldData := range getStructMetadata(e.typ).fields {
+				name, omitEmpty, named := parseMapperTag(fieldData, tagName)
+				if name == "-" {
+					continue
+				}
+
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				if embeddedStruct(fieldData, named) {
+					t := fieldData.Type
+					if t.Kind() == reflect.Ptr {
+						t = t.Elem()
+					}
+
+					next = append(next, embedded{typ: t, index: index})
+					continue
+				}
+
+				if !fieldData.Exported || resolved[name] {
+					continue
+				}
+
+				if _, ok := candidates[name]; !ok {
+					names = append(names, name)
+				}
+
+				candidates[name] = append(candidates[name], mapperField{name: name, index: index, omitEmpty: omitEmpty})
+			}
+		}
+
+		for _, name := range names {
+			resolved[name] = true
+			if len(candidates[name]) == 1 {
+				fields = append(fields, candidates[name][0])
+			}
+		}
+
+		current = next
+	}
+
+	return fields
+}
+
+func structToJSONMap(v reflect.Value, o MapperOptions) (types.JSONMap, error) {
+	if v.Kind() != reflect.Struct {
+		return nil, errors.New("argument is not a struct")
+	}
+
+	result := types.JSONMap{}
+
+	for _, f := range getStructMetadata(v.Type()).mapperFields(o.TagName) {
+		// Fields promoted through nil embedded pointers are skipped
+		field, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+
+		if f.omitEmpty && Empty(field.Interface()) {
+			continue
+		}
+
+		value, err := valueToJSONMapValue(field, o)
+		if err != nil {
+			return nil, err
+		}
+
+		result[f.name] = value
+	}
+
+	return result, nil
+}
+
+func valueToJSONMapValue(v reflect.Value, o MapperOptions) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		return valueToJSONMapValue(v.Elem(), o)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			if o.TimeFormat != "" {
+				return v.Interface().(time.Time).Format(o.TimeFormat), nil
+			}
+
+			return v.Interface(), nil
+		}
+
+		return structToJSONMap(v, o)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface(), nil
+		}
+
+		result := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, err := valueToJSONMapValue(v.Index(i), o)
+			if err != nil {
+				return nil, err
+			}
+
+			result[i] = value
+		}
+
+		return result, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		result := make(types.JSONMap, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := valueToJSONMapValue(iter.Value(), o)
+			if err != nil {
+				return nil, err
+			}
+
+			result[fmt.Sprint(iter.Key().Interface())] = value
+		}
+
+		return result, nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// mapToStruct fills given struct value with given map data
+func mapToStruct(m map[string]interface{}, out reflect.Value, o MapperOptions, path string) error {
+	for _, f := range getStructMetadata(out.Type()).mapperFields(o.TagName) {
+		fieldPath := joinFieldPath(path, f.name)
+
+		value, ok, err := lookupMapKey(m, f.name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", fieldPath, err)
+		}
+
+		if !ok {
+			continue
+		}
+
+		// Embedded pointers are allocated only for the present keys
+		field, err := allocFieldByIndex(out, f.index)
+		if err != nil {
+			return fmt.Errorf("%s: %v", fieldPath, err)
+		}
+
+		if err := mapValueToValue(value, field, o, fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupMapKey finds given key in map, preferring an exact match over a
+// case insensitive one. It returns an error if there is no exact match and
+// more than one key matches case insensitively, e.g. "NAME" and "nAme".
+func lookupMapKey(m map[string]interface{}, key string) (interface{}, bool, error) {
+	if value, ok := m[key]; ok {
+		return value, true, nil
+	}
+
+	var matches []string
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			matches = append(matches, k)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return m[matches[0]], true, nil
+	default:
+		sort.Strings(matches)
+		return nil, false, fmt.Errorf("ambiguous keys %q", matches)
+	}
+}
+
+func mapValueToValue(input interface{}, out reflect.Value, o MapperOptions, path string) error {
+	if input == nil {
+		out.Set(reflect.Zero(out.Type()))
+		return nil
+	}
+
+	in := reflect.ValueOf(input)
+
+	switch {
+	case out.Kind() == reflect.Ptr:
+		elem := reflect.New(out.Type().Elem())
+		if err := mapValueToValue(input, elem.Elem(), o, path); err != nil {
+			return err
+		}
+
+		out.Set(elem)
+	case out.Type() == timeType:
+		t, err := mapValueToTime(in, o)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		out.Set(reflect.ValueOf(t))
+	case out.Kind() == reflect.Struct:
+		m, ok := toStringMap(in)
+		if !ok {
+			return fmt.Errorf("%s: cannot map %s into %s", path, in.Type(), out.Type())
+		}
+
+		return mapToStruct(m, out, o, path)
+	case out.Kind() == reflect.Slice && !(in.Kind() == reflect.String && out.Type().Elem().Kind() == reflect.Uint8):
+		if in.Kind() != reflect.Slice && in.Kind() != reflect.Array {
+			if !o.WeaklyTypedInput {
+				return fmt.Errorf("%s: cannot map %s into %s", path, in.Type(), out.Type())
+			}
+
+			in = reflect.ValueOf([]interface{}{input})
+		}
+
+		slice := reflect.MakeSlice(out.Type(), in.Len(), in.Len())
+		for i := 0; i < in.Len(); i++ {
+			if err := mapValueToValue(in.Index(i).Interface(), slice.Index(i), o, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		out.Set(slice)
+	case out.Kind() == reflect.Array:
+		if in.Kind() != reflect.Slice && in.Kind() != reflect.Array {
+			return fmt.Errorf("%s: cannot map %s into %s", path, in.Type(), out.Type())
+		}
+
+		if in.Len() > out.Len() {
+			return fmt.Errorf("%s: %d elements do not fit into %s", path, in.Len(), out.Type())
+		}
+
+		array := reflect.New(out.Type()).Elem()
+		for i := 0; i < in.Len(); i++ {
+			if err := mapValueToValue(in.Index(i).Interface(), array.Index(i), o, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		out.Set(array)
+	case out.Kind() == reflect.Map:
+		if in.Kind() != reflect.Map {
+			return fmt.Errorf("%s: cannot map %s into %s", path, in.Type(), out.Type())
+		}
+
+		m := reflect.MakeMapWithSize(out.Type(), in.Len())
+		iter := in.MapRange()
+		for iter.Next() {
+			key, err := convertValue(iter.Key(), out.Type().Key(), true)
+			if err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+
+			elem := reflect.New(out.Type().Elem()).Elem()
+			elemPath := fmt.Sprintf("%s[%v]", path, iter.Key().Interface())
+			if err := mapValueToValue(iter.Value().Interface(), elem, o, elemPath); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(key, elem)
+		}
+
+		out.Set(m)
+	default:
+		value, err := convertValue(in, out.Type(), o.WeaklyTypedInput)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		out.Set(value)
+	}
+
+	return nil
+}
+
+func mapValueToTime(in reflect.Value, o MapperOptions) (time.Time, error) {
+	switch {
+	case in.Type() == timeType:
+		return in.Interface().(time.Time), nil
+	case in.Kind() == reflect.String:
+		layout := o.TimeFormat
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+
+		return time.Parse(layout, in.String())
+	case o.WeaklyTypedInput && isNumber(in.Kind()):
+		seconds, err := convertNumber(in, reflect.TypeOf(int64(0)))
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(seconds.Int(), 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("cannot convert %s to time.Time", in.Type())
+}
+
+// toStringMap converts given map with string keys to map[string]interface{}
+func toStringMap(v reflect.Value) (map[string]interface{}, bool) {
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	if m, ok := v.Interface().(types.JSONMap); ok {
+		return m, true
+	}
+
+	if m, ok := v.Interface().(map[string]interface{}); ok {
+		return m, true
+	}
+
+	m := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+
+	return m, true
+}
+
+// joinFieldPath appends given name to a dotted field path
+func joinFieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
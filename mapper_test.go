@@ -0,0 +1,264 @@
+package nautilus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kamva/nautilus/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapperBase struct {
+	ID        int       `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type mapperAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type mapperUser struct {
+	mapperBase
+	*MapperMeta
+	Name     string            `json:"name"`
+	Email    *string           `json:"email,omitempty"`
+	Age      int               `json:"age,omitempty"`
+	Password string            `json:"-"`
+	Address  mapperAddress     `json:"address"`
+	Tags     []string          `json:"tags"`
+	Scores   map[string]int    `json:"scores,omitempty"`
+	Backup   *mapperAddress    `json:"backup,omitempty"`
+	Extra    map[string]string `custom:"more"`
+	hidden   string
+}
+
+type MapperMeta struct {
+	Version int `json:"version"`
+}
+
+type MapperEmbedded struct {
+	Note string `json:"note"`
+}
+
+type mapperMeta struct {
+	Version int `json:"version"`
+}
+
+type MapperNote struct {
+	Note string `map:"note"`
+}
+
+type MapperAudit struct {
+	Note    string `map:"note"`
+	Version int    `map:"version"`
+}
+
+type mapperConflicting struct {
+	MapperNote
+	MapperAudit
+	Name string `map:"name"`
+}
+
+type mapperWithExported struct {
+	MapperEmbedded
+	*mapperMeta
+	Note string `json:"note"`
+}
+
+func TestToJSONMap(t *testing.T) {
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	email := "user@example.com"
+
+	t.Run("struct", func(t *testing.T) {
+		u := mapperUser{
+			mapperBase: mapperBase{ID: 1, CreatedAt: createdAt},
+			Name:       "John",
+			Email:      &email,
+			Password:   "secret",
+			Address:    mapperAddress{City: "Tehran"},
+			Tags:       []string{"a", "b"},
+			Scores:     map[string]int{},
+			hidden:     "hidden",
+		}
+
+		m, err := ToJSONMap(u)
+
+		assert.Nil(t, err)
+		assert.Equal(t, types.JSONMap{
+			"id":         1,
+			"created_at": createdAt,
+			"name":       "John",
+			"email":      "user@example.com",
+			"address":    types.JSONMap{"city": "Tehran"},
+			"tags":       []interface{}{"a", "b"},
+			"Extra":      nil,
+		}, m)
+	})
+	t.Run("promoted fields of exported embedded struct", func(t *testing.T) {
+		s := &mapperWithExported{MapperEmbedded: MapperEmbedded{Note: "inner"}, Note: "outer"}
+		m, err := ToJSONMap(s)
+
+		assert.Nil(t, err)
+		assert.Equal(t, types.JSONMap{"note": "outer"}, m)
+	})
+	t.Run("conflicting promoted fields", func(t *testing.T) {
+		s := mapperConflicting{
+			MapperNote:  MapperNote{Note: "embedded"},
+			MapperAudit: MapperAudit{Note: "audit", Version: 2},
+			Name:        "name",
+		}
+		options := MapperOptions{TagName: "map"}
+
+		m, err := ToJSONMap(s, options)
+
+		// Like reflect.Value.FieldByName, ambiguous "note" is dropped
+		assert.Nil(t, err)
+		assert.Equal(t, types.JSONMap{"version": 2, "name": "name"}, m)
+
+		var result mapperConflicting
+		assert.Nil(t, FromJSONMap(types.JSONMap{"note": "x", "version": float64(3)}, &result, options))
+		assert.Equal(t, mapperConflicting{MapperAudit: MapperAudit{Version: 3}}, result)
+	})
+	t.Run("nested maps", func(t *testing.T) {
+		type document struct {
+			Plain  map[string]interface{} `json:"plain"`
+			JSON   types.JSONMap          `json:"json"`
+			Struct mapperAddress          `json:"struct"`
+			Any    interface{}            `json:"any"`
+		}
+
+		m, err := ToJSONMap(document{
+			Plain:  map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+			JSON:   types.JSONMap{"a": types.JSONMap{"b": 1}},
+			Struct: mapperAddress{City: "Tehran"},
+			Any:    map[string]int{"b": 1},
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, types.JSONMap{
+			"plain":  types.JSONMap{"a": types.JSONMap{"b": 1}},
+			"json":   types.JSONMap{"a": types.JSONMap{"b": 1}},
+			"struct": types.JSONMap{"city": "Tehran"},
+			"any":    types.JSONMap{"b": 1},
+		}, m)
+	})
+	t.Run("options", func(t *testing.T) {
+		type event struct {
+			Name string    `custom:"title"`
+			At   time.Time `custom:"at"`
+		}
+
+		m, err := ToJSONMap(event{Name: "start", At: createdAt}, MapperOptions{TagName: "custom", TimeFormat: time.RFC3339})
+
+		assert.Nil(t, err)
+		assert.Equal(t, types.JSONMap{"title": "start", "at": "2020-01-02T03:04:05Z"}, m)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ToJSONMap(42)
+		assert.NotNil(t, err)
+
+		_, err = ToJSONMap((*mapperUser)(nil))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestFromJSONMap(t *testing.T) {
+	t.Run("struct", func(t *testing.T) {
+		m := types.JSONMap{
+			"id":         float64(7),
+			"created_at": "2020-01-02T03:04:05Z",
+			"version":    float64(2),
+			"NAME":       "John",
+			"email":      "user@example.com",
+			"address":    map[string]interface{}{"city": "Tehran", "zip": "1234"},
+			"tags":       []interface{}{"a", "b"},
+			"scores":     types.JSONMap{"math": float64(20)},
+			"backup":     map[string]interface{}{"city": "Shiraz"},
+			"Password":   "ignored",
+		}
+
+		var u mapperUser
+		err := FromJSONMap(m, &u)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 7, u.ID)
+		assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), u.CreatedAt)
+		assert.NotNil(t, u.MapperMeta)
+		assert.Equal(t, 2, u.Version)
+		assert.Equal(t, "John", u.Name)
+		assert.Equal(t, "user@example.com", *u.Email)
+		assert.Equal(t, "", u.Password)
+		assert.Equal(t, mapperAddress{City: "Tehran", Zip: "1234"}, u.Address)
+		assert.Equal(t, []string{"a", "b"}, u.Tags)
+		assert.Equal(t, map[string]int{"math": 20}, u.Scores)
+		assert.Equal(t, &mapperAddress{City: "Shiraz"}, u.Backup)
+	})
+	t.Run("round trip", func(t *testing.T) {
+		email := "user@example.com"
+		u := mapperUser{
+			mapperBase: mapperBase{ID: 1, CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+			Name:       "John",
+			Email:      &email,
+			Address:    mapperAddress{City: "Tehran"},
+			Scores:     map[string]int{"math": 20},
+		}
+
+		m, err := ToJSONMap(&u)
+		assert.Nil(t, err)
+
+		var result mapperUser
+		assert.Nil(t, FromJSONMap(m, &result))
+		assert.Equal(t, u, result)
+	})
+	t.Run("weakly typed input", func(t *testing.T) {
+		type config struct {
+			Port    int           `json:"port"`
+			Debug   bool          `json:"debug"`
+			Name    string        `json:"name"`
+			Timeout time.Duration `json:"timeout"`
+			Hosts   []string      `json:"hosts"`
+		}
+
+		m := types.JSONMap{"port": "8080", "debug": float64(1), "name": float64(12), "timeout": "5s", "hosts": "localhost"}
+
+		var c config
+		assert.NotNil(t, FromJSONMap(m, &c))
+
+		err := FromJSONMap(m, &c, MapperOptions{WeaklyTypedInput: true})
+
+		assert.Nil(t, err)
+		assert.Equal(t, config{Port: 8080, Debug: true, Name: "12", Timeout: 5 * time.Second, Hosts: []string{"localhost"}}, c)
+	})
+	t.Run("errors", func(t *testing.T) {
+		type numbers struct {
+			Small int8    `json:"small"`
+			Whole int     `json:"whole"`
+			List  []uint8 `json:"list"`
+		}
+
+		var n numbers
+
+		err := FromJSONMap(types.JSONMap{"small": float64(300)}, &n)
+		assert.EqualError(t, err, "small: value 300 overflows int8")
+
+		err = FromJSONMap(types.JSONMap{"whole": 1.5}, &n)
+		assert.EqualError(t, err, "whole: cannot convert 1.5 to int without losing precision")
+
+		err = FromJSONMap(types.JSONMap{"list": []interface{}{float64(1), float64(-1)}}, &n)
+		assert.EqualError(t, err, "list[1]: value -1 overflows uint8")
+
+		// Keys which only differ in case are ambiguous without an exact match
+		err = FromJSONMap(types.JSONMap{"Small": float64(1), "SMALL": float64(2)}, &n)
+		assert.EqualError(t, err, `small: ambiguous keys ["SMALL" "Small"]`)
+
+		assert.Nil(t, FromJSONMap(types.JSONMap{"small": float64(1), "SMALL": float64(2)}, &n))
+		assert.Equal(t, int8(1), n.Small)
+
+		assert.NotNil(t, FromJSONMap(types.JSONMap{}, n))
+
+		var s mapperWithExported
+		err = FromJSONMap(types.JSONMap{"version": float64(1)}, &s)
+		assert.EqualError(t, err, "version: cannot set embedded pointer to unexported struct nautilus.mapperMeta")
+	})
+}
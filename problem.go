@@ -0,0 +1,233 @@
+package nautilus
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kataras/iris/v12"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// ErrorFormat is the format of error responses
+type ErrorFormat int
+
+const (
+	// ErrorFormatJSON renders errors as AppError JSON documents, which have
+	// message, code, details and errors members
+	ErrorFormatJSON ErrorFormat = iota
+
+	// ErrorFormatProblem renders errors as RFC 7807 problem details
+	ErrorFormatProblem
+
+	// ErrorFormatNegotiate renders errors as problem details if the client
+	// prefers application/problem+json to application/json in its Accept
+	// header, and as AppError JSON documents otherwise
+	ErrorFormatNegotiate
+)
+
+// ErrorRendererOptions contains options of rendering error responses
+type ErrorRendererOptions struct {
+	Format ErrorFormat
+
+	// TypeBaseURL is prefixed to the code of errors to build the type of
+	// problem details, e.g. "https://example.com/errors/" results in
+	// "https://example.com/errors/user_not_found". Problems have
+	// "about:blank" type if it is empty, unless the AppError has a type.
+	TypeBaseURL string
+
+	// Translator localizes messages of errors, using their code as the
+	// message key and their details as params. Errors keep their message
+	// if it has no translation. Responses have "Vary: Accept-Language"
+	// header if it is set.
+	Translator Translator
+}
+
+// Problem is an RFC 7807 problem details document. Extensions are
+// rendered as members of the document next to the standard members.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON implements json.Marshaler
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	m["type"] = p.Type
+	m["title"] = p.Title
+	m["status"] = p.Status
+
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return json.Marshal(m)
+}
+
+// Problem returns the error as problem details. Its title is the status
+// text and its detail is the message of the error. The code, details and
+// field errors of the error are rendered as "code", "details" and "errors"
+// extensions.
+func (e *AppError) Problem(instance string, opts ...ErrorRendererOptions) Problem {
+	o := mergeErrorRendererOptions(opts)
+
+	status := e.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	problemType := e.Type
+	if problemType == "" {
+		problemType = "about:blank"
+		if o.TypeBaseURL != "" && e.Code != "" {
+			problemType = o.TypeBaseURL + e.Code
+		}
+	}
+
+	problem := Problem{
+		Type:       problemType,
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     e.Message,
+		Instance:   instance,
+		Extensions: make(map[string]interface{}),
+	}
+
+	if e.Code != "" {
+		problem.Extensions["code"] = e.Code
+	}
+
+	if len(e.Details) > 0 {
+		problem.Extensions["details"] = e.Details
+	}
+
+	if e.Fields != nil {
+		problem.Extensions["errors"] = e.Fields
+	}
+
+	return problem
+}
+
+// NewErrorRenderer returns an error handler which renders the error of the
+// context (see GetError) in the format of given options, localizing its
+// message if a translator is given
+func NewErrorRenderer(opts ...ErrorRendererOptions) iris.Handler {
+	o := mergeErrorRendererOptions(opts)
+
+	return func(ctx iris.Context) {
+		appErr := GetError(ctx)
+		if o.Translator != nil && appErr.Code != "" {
+			if message, ok := o.Translator.Translate(ctx, appErr.Code, appErr.Details); ok {
+				localized := *appErr
+				localized.Message = message
+				appErr = &localized
+			}
+		}
+
+		// Responses depend on the Accept header when it is negotiated, so
+		// caches should not serve them for other Accept headers
+		if o.Format == ErrorFormatNegotiate {
+			ctx.Header("Vary", "Accept")
+		}
+
+		// Likewise, messages depend on the Accept-Language header when they
+		// are translated
+		if o.Translator != nil {
+			ctx.Header("Vary", "Accept-Language")
+		}
+
+		if o.Format == ErrorFormatJSON || o.Format == ErrorFormatNegotiate && !acceptsProblem(ctx.GetHeader("Accept")) {
+			_, _ = ctx.JSON(appErr)
+			return
+		}
+
+		body, err := json.Marshal(appErr.Problem(ctx.Request().URL.RequestURI(), o))
+		if err != nil {
+			_, _ = ctx.JSON(appErr)
+			return
+		}
+
+		ctx.ContentType(ProblemContentType)
+		_, _ = ctx.Write(body)
+	}
+}
+
+func mergeErrorRendererOptions(opts []ErrorRendererOptions) ErrorRendererOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+
+	return ErrorRendererOptions{}
+}
+
+// acceptsProblem checks whether given Accept header prefers problem
+// details to JSON, considering quality values of media ranges. Quality of
+// each type is taken from its most specific matching range, so wildcards
+// such as "*/*" and "application/*" apply to both types. Since JSON is the
+// default, problem details are preferred on equal qualities only if they
+// are requested explicitly.
+func acceptsProblem(accept string) bool {
+	// Specificity of the range which the quality is taken from, which is
+	// 0 for no match, 1 for "*/*", 2 for "application/*" and 3 for exact
+	var problemQuality, jsonQuality float64
+	var problemSpecificity, jsonSpecificity int
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		params := strings.Split(mediaRange, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			pair := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(pair) == 2 && strings.TrimSpace(pair[0]) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(pair[1]), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		switch mediaType {
+		case "*/*":
+			if problemSpecificity < 1 {
+				problemQuality, problemSpecificity = quality, 1
+			}
+
+			if jsonSpecificity < 1 {
+				jsonQuality, jsonSpecificity = quality, 1
+			}
+		case "application/*":
+			if problemSpecificity < 2 {
+				problemQuality, problemSpecificity = quality, 2
+			}
+
+			if jsonSpecificity < 2 {
+				jsonQuality, jsonSpecificity = quality, 2
+			}
+		case ProblemContentType:
+			problemQuality, problemSpecificity = quality, 3
+		case "application/json":
+			jsonQuality, jsonSpecificity = quality, 3
+		}
+	}
+
+	if problemQuality <= 0 {
+		return false
+	}
+
+	return problemQuality > jsonQuality || problemQuality == jsonQuality && problemSpecificity == 3
+}
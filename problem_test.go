@@ -0,0 +1,158 @@
+package nautilus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblem(t *testing.T) {
+	err := NewAppError(http.StatusNotFound, "user_not_found", "user 1 not found").
+		WithDetails(map[string]interface{}{"id": 1})
+
+	problem := err.Problem("/users/1")
+	assert.Equal(t, Problem{
+		Type:       "about:blank",
+		Title:      "Not Found",
+		Status:     404,
+		Detail:     "user 1 not found",
+		Instance:   "/users/1",
+		Extensions: map[string]interface{}{"code": "user_not_found", "details": map[string]interface{}{"id": 1}},
+	}, problem)
+
+	body, _ := problem.MarshalJSON()
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "user 1 not found",
+		"instance": "/users/1",
+		"code": "user_not_found",
+		"details": {"id": 1}
+	}`, string(body))
+
+	problem = err.Problem("", ErrorRendererOptions{TypeBaseURL: "https://example.com/errors/"})
+	assert.Equal(t, "https://example.com/errors/user_not_found", problem.Type)
+
+	typed := *err
+	typed.Type = "https://example.com/not-found"
+	assert.Equal(t, "https://example.com/not-found", typed.Problem("", ErrorRendererOptions{TypeBaseURL: "x"}).Type)
+
+	assert.Equal(t, 500, (&AppError{}).Problem("").Status)
+}
+
+func TestAcceptsProblem(t *testing.T) {
+	assert.True(t, acceptsProblem("application/problem+json"))
+	assert.True(t, acceptsProblem("application/json, application/problem+json"))
+	assert.True(t, acceptsProblem("application/json;q=0.5, application/problem+json;q=0.9"))
+	assert.False(t, acceptsProblem("application/json, application/problem+json;q=0.5"))
+	assert.False(t, acceptsProblem("application/problem+json;q=0"))
+	assert.False(t, acceptsProblem("*/*"))
+	assert.False(t, acceptsProblem("application/*"))
+	assert.False(t, acceptsProblem(""))
+
+	// Wildcards apply to both types and the most specific range wins
+	assert.True(t, acceptsProblem("application/problem+json, */*"))
+	assert.True(t, acceptsProblem("application/problem+json, application/*;q=0.5"))
+	assert.True(t, acceptsProblem("application/problem+json;q=0.8, */*;q=0.5"))
+	assert.False(t, acceptsProblem("application/problem+json;q=0.5, */*"))
+	assert.False(t, acceptsProblem("application/problem+json;q=0.5, application/*"))
+	assert.True(t, acceptsProblem("*/*;q=0.1, application/*;q=0.2, application/json;q=0.1"))
+	assert.False(t, acceptsProblem("application/*;q=0, application/json"))
+}
+
+// headerTranslator translates messages to the Accept-Language header
+type headerTranslator struct{}
+
+func (headerTranslator) Translate(ctx iris.Context, key string, params map[string]interface{}) (string, bool) {
+	lang := ctx.GetHeader("Accept-Language")
+	return key + " in " + lang, lang != ""
+}
+
+func TestNewErrorRenderer(t *testing.T) {
+	newApp := func(opts ...ErrorRendererOptions) *iris.Application {
+		app := iris.New()
+		app.OnAnyErrorCode(NewErrorRenderer(opts...))
+		app.Get("/users/{id}", HandleErrors(func(ctx iris.Context) error {
+			return NewAppError(http.StatusNotFound, "user_not_found", "user not found")
+		}))
+
+		assert.Nil(t, app.Build())
+		return app
+	}
+
+	serve := func(app *iris.Application, path string, accept string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Accept", accept)
+		app.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	jsonBody := `{"code":"user_not_found","message":"user not found"}`
+	problemBody := `{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "user not found",
+		"instance": "/users/1?expand=true",
+		"code": "user_not_found"
+	}`
+
+	t.Run("json", func(t *testing.T) {
+		response := serve(newApp(), "/users/1", ProblemContentType)
+		assert.Equal(t, 404, response.Code)
+		assert.Contains(t, response.Header().Get("Content-Type"), "application/json")
+		assert.JSONEq(t, jsonBody, response.Body.String())
+	})
+	t.Run("problem", func(t *testing.T) {
+		response := serve(newApp(ErrorRendererOptions{Format: ErrorFormatProblem}), "/users/1?expand=true", "")
+		assert.Equal(t, 404, response.Code)
+		assert.Contains(t, response.Header().Get("Content-Type"), ProblemContentType)
+		assert.JSONEq(t, problemBody, response.Body.String())
+
+		response = serve(newApp(ErrorRendererOptions{Format: ErrorFormatProblem}), "/missing", "")
+		assert.Empty(t, response.Header().Get("Vary"))
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Not Found",
+			"status": 404,
+			"detail": "Not Found",
+			"instance": "/missing",
+			"code": "not_found"
+		}`, response.Body.String())
+	})
+	t.Run("negotiate", func(t *testing.T) {
+		app := newApp(ErrorRendererOptions{Format: ErrorFormatNegotiate})
+
+		response := serve(app, "/users/1?expand=true", "application/problem+json, application/json;q=0.8")
+		assert.Contains(t, response.Header().Get("Content-Type"), ProblemContentType)
+		assert.Equal(t, "Accept", response.Header().Get("Vary"))
+		assert.JSONEq(t, problemBody, response.Body.String())
+
+		response = serve(app, "/users/1", "application/json")
+		assert.Contains(t, response.Header().Get("Content-Type"), "application/json")
+		assert.Equal(t, "Accept", response.Header().Get("Vary"))
+		assert.JSONEq(t, jsonBody, response.Body.String())
+	})
+	t.Run("translated", func(t *testing.T) {
+		app := newApp(ErrorRendererOptions{Format: ErrorFormatNegotiate, Translator: headerTranslator{}})
+
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		request.Header.Set("Accept-Language", "fa")
+		app.ServeHTTP(recorder, request)
+
+		assert.Equal(t, []string{"Accept", "Accept-Language"}, recorder.Header().Values("Vary"))
+		assert.JSONEq(t, `{"code":"user_not_found","message":"user_not_found in fa"}`, recorder.Body.String())
+
+		// Responses vary even if the message has no translation
+		response := serve(newApp(ErrorRendererOptions{Translator: headerTranslator{}}), "/users/1", "")
+		assert.Equal(t, "Accept-Language", response.Header().Get("Vary"))
+		assert.JSONEq(t, jsonBody, response.Body.String())
+	})
+}
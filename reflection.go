@@ -1,11 +1,19 @@
 package nautilus
 
 import (
+	"encoding"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 )
 
+var (
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
 // FieldData containing struct field data such as name, type and tag
 type FieldData struct {
 	Name      string
@@ -14,28 +22,79 @@ type FieldData struct {
 	Value     interface{}
 	Exported  bool
 	Anonymous bool
+
+	// Index is the index sequence of the field for reflect.Value.FieldByIndex
+	// and Path is its dotted name, which are different from the field index
+	// and name for fields of nested or embedded structs
+	Index []int
+	Path  string
+}
+
+// MethodData contains data of an exported method of a type
+type MethodData struct {
+	Name string
+
+	// Type is the func type of the method without its receiver and
+	// Signature is its short name, e.g. "func(context.Context) error"
+	// is described as "func(Context) error"
+	Type      reflect.Type
+	Signature string
+
+	// Index is the index of the method for reflect.Value.Method
+	Index int
+}
+
+// FieldsDataOptions contains options of walking struct fields recursively
+// in GetStructFieldsData
+type FieldsDataOptions struct {
+	// FlattenEmbedded replaces embedded structs with their promoted fields,
+	// following Go visibility rules, so shadowed and ambiguous fields are
+	// not included. Path of promoted fields is their own name. Embedded
+	// structs which are single values, i.e. implement json.Marshaler,
+	// encoding.TextMarshaler or `IsZero() bool` such as time.Time, are
+	// kept as a single field.
+	FlattenEmbedded bool
+
+	// Nested replaces nested struct fields (and pointers to structs) with
+	// their fields, which their path is prefixed by the parent name, e.g.
+	// "Address.City". Structs which are single values such as time.Time
+	// and URLs are not walked.
+	Nested bool
+
+	// IncludeUnexported includes unexported fields, without their values
+	IncludeUnexported bool
 }
 
-// GetType get the type name of a struct variable
+// GetType get the short type name of a variable
 // For example, if v is an int, GetType(v) return int as string
-// Or if v is a struct or custom type, GetType(v) return type name
+// Or if v is a struct or custom type, GetType(v) return type name.
+// Pointers are dereferenced, so GetType(&user) returns "User" too. Other
+// types are described in Go syntax, such as "[]User" or "map[string]int",
+// and an empty string is returned for nil. See GetTypeName for qualified
+// names of types.
 func GetType(v interface{}) string {
 	t := reflect.TypeOf(v)
-
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+	if t == nil {
+		return ""
 	}
 
-	if t.Kind() == reflect.Struct {
-		return t.Name()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
 
-	panic("argument is not an struct")
+	return formatType(t, false)
 }
 
 // GetStructFieldsData analyze the given struct and return information
-// about exported fields such as name, type and tag value
-func GetStructFieldsData(i interface{}) ([]FieldData, error) {
+// about exported fields such as name, type and tag value. Metadata of each
+// struct type is analyzed once and cached, so only values are read on
+// subsequent calls.
+//
+// Without options, all top-level fields are returned. If options are given,
+// fields are walked recursively based on them and unexported fields are
+// included only if IncludeUnexported is set. Values of fields which are
+// promoted through nil pointers are nil.
+func GetStructFieldsData(i interface{}, opts ...FieldsDataOptions) ([]FieldData, error) {
 	t := reflect.TypeOf(i)
 	v := reflect.ValueOf(i)
 	if t.Kind() == reflect.Ptr {
@@ -47,187 +106,401 @@ func GetStructFieldsData(i interface{}) ([]FieldData, error) {
 		return nil, errors.New("argument is not a struct")
 	}
 
-	var ret []FieldData
-	for i := 0; i < t.NumField(); i++ {
-		fieldData := FieldData{}
-		fieldData.Name = t.Field(i).Name
-		fieldData.Type = t.Field(i).Type
-		fieldData.Tags = t.Field(i).Tag
-		fieldData.Exported = t.Field(i).PkgPath == ""
-		fieldData.Anonymous = t.Field(i).Anonymous
+	if len(opts) > 0 {
+		fields := getStructMetadata(t).flattenedFields(opts[0])
+		ret := make([]FieldData, len(fields))
+		for i, fieldData := range fields {
+			if field, ok := fieldByIndex(v, fieldData.Index); ok && field.CanInterface() {
+				fieldData.Value = field.Interface()
+			}
 
+			fieldData.Index = append([]int(nil), fieldData.Index...)
+			ret[i] = fieldData
+		}
+
+		return ret, nil
+	}
+
+	fields := getStructMetadata(t).fields
+	ret := make([]FieldData, len(fields))
+	for i, fieldData := range fields {
 		if fieldData.Exported {
 			fieldData.Value = v.Field(i).Interface()
-		} else {
-			fieldData.Value = nil
 		}
 
-		ret = append(ret, fieldData)
+		fieldData.Index = append([]int(nil), fieldData.Index...)
+		ret[i] = fieldData
 	}
 
 	return ret, nil
 }
 
-// PointerToValue converts given variable (if it is a pointer) to its value
+// flattenFields walks fields of given struct type based on given options.
+// Types in visiting are the ones which are being walked, so recursive types
+// are not walked infinitely.
+func flattenFields(t reflect.Type, o FieldsDataOptions, visiting map[reflect.Type]bool) []FieldData {
+	var fields []reflect.StructField
+	if o.FlattenEmbedded {
+		fields = promotedFields(t)
+	} else {
+		for i := 0; i < t.NumField(); i++ {
+			fields = append(fields, t.Field(i))
+		}
+	}
+
+	var ret []FieldData
+	for _, field := range fields {
+		exported := field.PkgPath == ""
+		if !exported && !o.IncludeUnexported {
+			continue
+		}
+
+		elem := field.Type
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+
+		if o.Nested && exported && isNestedStruct(field.Type) && !visiting[elem] {
+			visiting[elem] = true
+			for _, nested := range flattenFields(elem, o, visiting) {
+				nested.Index = append(append([]int(nil), field.Index...), nested.Index...)
+				nested.Path = field.Name + "." + nested.Path
+				ret = append(ret, nested)
+			}
+			delete(visiting, elem)
+
+			continue
+		}
+
+		ret = append(ret, FieldData{
+			Name:      field.Name,
+			Type:      field.Type,
+			Tags:      field.Tag,
+			Exported:  exported,
+			Anonymous: field.Anonymous,
+			Index:     field.Index,
+			Path:      field.Name,
+		})
+	}
+
+	return ret
+}
+
+// isValueStruct checks whether given struct type is handled as a single
+// value, as it marshals itself or defines its own zero value
+func isValueStruct(t reflect.Type) bool {
+	for _, iface := range []reflect.Type{jsonMarshalerType, textMarshalerType, zeroerType} {
+		if t.Implements(iface) || reflect.PtrTo(t).Implements(iface) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// promotedFields returns fields of given struct type in which embedded
+// structs are replaced by their fields, in the order of declaration. Fields
+// which are shadowed by shallower fields or are ambiguous (have the same
+// name in the same depth) are not accessible by their names, so they are
+// excluded.
+func promotedFields(t reflect.Type) []reflect.StructField {
+	var candidates []reflect.StructField
+
+	var walk func(t reflect.Type, index []int, visiting map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, visiting map[reflect.Type]bool) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			field.Index = append(append([]int(nil), index...), i)
+
+			elem := field.Type
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+
+			if field.Anonymous && elem.Kind() == reflect.Struct && !visiting[elem] && !isValueStruct(elem) {
+				visiting[elem] = true
+				walk(elem, field.Index, visiting)
+				delete(visiting, elem)
+
+				continue
+			}
+
+			candidates = append(candidates, field)
+		}
+	}
+
+	walk(t, nil, map[reflect.Type]bool{t: true})
+
+	// FieldByName applies the visibility rules, so candidates which are not
+	// the result of looking up their own name are hidden
+	metadata := getStructMetadata(t)
+	fields := candidates[:0]
+	for _, candidate := range candidates {
+		field, ok := metadata.fieldByName(candidate.Name)
+		if ok && reflect.DeepEqual(field.Index, candidate.Index) {
+			fields = append(fields, candidate)
+		}
+	}
+
+	return fields
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports false
+// instead of panicking when the path goes through a nil pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+// PointerToValue converts given variable (if it is a pointer) to its value.
+// Pointers of any depth are dereferenced, so a **int results in an int.
+// If any of the pointers is nil, the result is nil.
 func PointerToValue(i interface{}) interface{} {
-	if reflect.ValueOf(i).Kind() == reflect.Ptr {
-		t := reflect.TypeOf(i).Elem()
-
-		// TODO: handle maps, arrays and slices
-		switch t.Kind() {
-		case reflect.Bool:
-			ptr := i.(*bool)
-			return *ptr
-		case reflect.Int:
-			ptr := i.(*int)
-			return *ptr
-		case reflect.Int8:
-			ptr := i.(*int8)
-			return *ptr
-		case reflect.Int16:
-			ptr := i.(*int16)
-			return *ptr
-		case reflect.Int32:
-			ptr := i.(*int32)
-			return *ptr
-		case reflect.Int64:
-			ptr := i.(*int64)
-			return *ptr
-		case reflect.Uint:
-			ptr := i.(*uint)
-			return *ptr
-		case reflect.Uint8:
-			ptr := i.(*uint8)
-			return *ptr
-		case reflect.Uint16:
-			ptr := i.(*uint16)
-			return *ptr
-		case reflect.Uint32:
-			ptr := i.(*uint32)
-			return *ptr
-		case reflect.Uint64:
-			ptr := i.(*uint64)
-			return *ptr
-		case reflect.Float32:
-			ptr := i.(*float32)
-			return *ptr
-		case reflect.Float64:
-			ptr := i.(*float64)
-			return *ptr
-		case reflect.Complex64:
-			ptr := i.(*complex64)
-			return *ptr
-		case reflect.Complex128:
-			ptr := i.(*complex128)
-			return *ptr
-		case reflect.String:
-			ptr := i.(*string)
-			return *ptr
-		case reflect.Struct:
-			ptr := i.(*bool)
-			return *ptr
-		default:
-			panic(fmt.Sprintf("Unsupported type [%s]", t.Kind().String()))
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr {
+		return i
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
 			return nil
 		}
+
+		v = v.Elem()
 	}
 
-	return i
+	return v.Interface()
 }
 
-// GetFieldPointer returns a pointer to given struct field with given field name
-func GetFieldPointer(i interface{}, fieldName string) interface{} {
+// ValueToPointer returns a pointer to a copy of given value. For example,
+// if i is a string, the result is a *string. It returns nil if i is nil.
+func ValueToPointer(i interface{}) interface{} {
+	if i == nil {
+		return nil
+	}
+
 	v := reflect.ValueOf(i)
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+
+	return ptr.Interface()
+}
 
-	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
-		panic("input must be a pointer to a struct")
-	}
-
-	fieldValue := v.Elem().FieldByName(fieldName)
-
-	if fieldValue != (reflect.Value{}) {
-		fieldInterface := fieldValue.Addr().Interface()
-		return interfaceToTypePtr(fieldValue.Type(), fieldInterface)
-	}
-
-	return nil
-}
-
-func interfaceToTypePtr(t reflect.Type, i interface{}) interface{} {
-	// TODO: handle maps, arrays and slices
-	switch t.Kind() {
-	case reflect.Bool:
-		return i.(*bool)
-	case reflect.Int:
-		return i.(*int)
-	case reflect.Int8:
-		return i.(*int8)
-	case reflect.Int16:
-		return i.(*int16)
-	case reflect.Int32:
-		return i.(*int32)
-	case reflect.Int64:
-		return i.(*int64)
-	case reflect.Uint:
-		return i.(*uint)
-	case reflect.Uint8:
-		return i.(*uint8)
-	case reflect.Uint16:
-		return i.(*uint16)
-	case reflect.Uint32:
-		return i.(*uint32)
-	case reflect.Uint64:
-		return i.(*uint64)
-	case reflect.Float32:
-		return i.(*float32)
-	case reflect.Float64:
-		return i.(*float64)
-	case reflect.Complex64:
-		return i.(*complex64)
-	case reflect.Complex128:
-		return i.(*complex128)
-	case reflect.String:
-		return i.(*string)
-	default:
-		panic(fmt.Sprintf("Unsupported type [%s]", t.Kind().String()))
+// GetFieldPointer returns a pointer to given struct field with given field name.
+// The type of returned value is a pointer to the field type, for example
+// *[]string for a []string field. Input must be a pointer to a struct.
+//
+// Field name could be a path expression like "Customer.Address.City",
+// "Items[2].Price" or `Meta["key"]`, resolving nested structs, promoted
+// fields of embedded structs, slice and array indices and map keys. It
+// does not modify the struct, so nil pointers and missing map keys along
+// the path return an error. Values stored in maps are not addressable, so
+// paths ending inside a map element (unless it is stored as a pointer)
+// return an error.
+func GetFieldPointer(i interface{}, fieldName string) (interface{}, error) {
+	var ptr interface{}
+
+	err := resolveFieldPath(i, fieldName, false, true, func(v reflect.Value) error {
+		ptr = v.Addr().Interface()
 		return nil
+	})
+
+	return ptr, err
+}
+
+// GetFieldValue returns value of given struct field with given field name.
+// Input could be a struct or a pointer to a struct and field name could be
+// a path expression, similar to GetFieldPointer. Like GetFieldPointer, it
+// does not modify the struct and returns an error if a nil pointer or a
+// missing map key is on the path. Unlike GetFieldPointer, the field does
+// not need to be addressable, so paths ending inside a map element are
+// allowed too.
+func GetFieldValue(i interface{}, fieldName string) (interface{}, error) {
+	v := reflect.ValueOf(i)
+	if v.Kind() == reflect.Struct {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		i = ptr.Interface()
 	}
+
+	var value interface{}
+
+	err := resolveFieldPath(i, fieldName, false, false, func(v reflect.Value) error {
+		if !v.CanInterface() {
+			return errors.New("value is obtained through an unexported field")
+		}
+
+		value = v.Interface()
+		return nil
+	})
+
+	return value, err
 }
 
-// SetFieldValue sets value of field with given name in given struct with given value
-func SetFieldValue(i interface{}, field string, value interface{}) {
+// SetFieldValue sets value of field with given name in given struct with given value.
+// Value is converted to the field type if it is assignable or safely
+// convertible, e.g. an int into an int64 field or a string into a field of a
+// named string type. Pointer values are dereferenced for non-pointer fields
+// and pointers are allocated for pointer fields. A nil value sets the field
+// to its zero value.
+//
+// Field name could be a path expression similar to GetFieldPointer. Nil
+// pointers and maps along the path are allocated and map elements are
+// updated in place. Nothing is allocated or modified if the path could not
+// be resolved or the value could not be converted.
+func SetFieldValue(i interface{}, field string, value interface{}) error {
+	set := func(v reflect.Value) error {
+		if err := assignValue(v, reflect.ValueOf(value), false); err != nil {
+			return fmt.Errorf("field %s: %v", field, err)
+		}
+
+		return nil
+	}
+
+	return resolveFieldPath(i, field, true, false, set, set)
+}
+
+// ConvertValue converts given value to the type that out points to and
+// stores it in out, using the same conversion rules as SetFieldValue.
+// For example a float64 holding a whole number could be converted to an
+// int and a *string to a string.
+func ConvertValue(value interface{}, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("output must be a non-nil pointer")
+	}
+
+	return assignValue(rv.Elem(), reflect.ValueOf(value), false)
+}
+
+// ListMethods returns exported methods of the type of given value, sorted
+// by name. Methods with pointer receivers are only included when a pointer
+// is given, following Go method sets.
+func ListMethods(i interface{}) []MethodData {
+	t := reflect.TypeOf(i)
+	if t == nil {
+		return nil
+	}
+
+	return append([]MethodData(nil), getMethodSet(t).methods...)
+}
+
+// HasMethod checks if given value has an exported method with given name.
+// Similar to ListMethods, methods with pointer receivers are only found
+// when a pointer is given.
+func HasMethod(i interface{}, name string) bool {
+	t := reflect.TypeOf(i)
+	if t == nil {
+		return false
+	}
+
+	_, ok := getMethodSet(t).method(name)
+	return ok
+}
+
+// ImplementsInterface checks if the type of given value implements an
+// interface, which could be given as a reflect.Type or a nil pointer to
+// the interface, e.g. (*io.Reader)(nil). It returns false if iface is not
+// an interface.
+func ImplementsInterface(i interface{}, iface interface{}) bool {
+	t := reflect.TypeOf(i)
+	if t == nil {
+		return false
+	}
+
+	it, ok := iface.(reflect.Type)
+	if !ok {
+		it = reflect.TypeOf(iface)
+		if it != nil && it.Kind() == reflect.Ptr {
+			it = it.Elem()
+		}
+	}
+
+	if it == nil || it.Kind() != reflect.Interface {
+		return false
+	}
+
+	return t.Implements(it)
+}
+
+// CallMethod calls the exported method of given value with given name and
+// arguments, and returns its results. Arguments are converted to the
+// parameter types using the same conversion rules as SetFieldValue, so an
+// int could be passed for an int64 parameter and nil for any parameter.
+// Variadic methods accept their variadic arguments individually.
+//
+// If the last result of the method is an error, it is returned as the error
+// of CallMethod and not included in the results. Missing methods, wrong
+// number of arguments and failed conversions are returned as errors too.
+func CallMethod(i interface{}, name string, args ...interface{}) ([]interface{}, error) {
 	v := reflect.ValueOf(i)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("cannot call method %s of nil", name)
+	}
+
+	data, ok := getMethodSet(v.Type()).method(name)
+	if !ok {
+		if _, ok := reflect.PtrTo(v.Type()).MethodByName(name); ok && v.Kind() != reflect.Ptr {
+			return nil, fmt.Errorf("method %s of %s has a pointer receiver", name, v.Type())
+		}
+
+		return nil, fmt.Errorf("method %s of %s not found", name, v.Type())
+	}
+
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, fmt.Errorf("cannot call method %s of nil %s", name, v.Type())
+	}
+
+	t := data.Type
+	if t.IsVariadic() && len(args) < t.NumIn()-1 {
+		return nil, fmt.Errorf("method %s expects at least %d arguments, got %d", name, t.NumIn()-1, len(args))
+	}
+
+	if !t.IsVariadic() && len(args) != t.NumIn() {
+		return nil, fmt.Errorf("method %s expects %d arguments, got %d", name, t.NumIn(), len(args))
+	}
 
-	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
-		panic("input must be a pointer to a struct")
-	}
-
-	fieldVal := v.Elem().FieldByName(field)
-
-	switch fieldVal.Kind() {
-	case reflect.Bool:
-		fieldVal.SetBool(value.(bool))
-	case reflect.Int:
-	case reflect.Int8:
-	case reflect.Int16:
-	case reflect.Int32:
-	case reflect.Int64:
-		fieldVal.SetInt(value.(int64))
-	case reflect.Uint:
-	case reflect.Uint8:
-	case reflect.Uint16:
-	case reflect.Uint32:
-	case reflect.Uint64:
-		fieldVal.SetUint(value.(uint64))
-	case reflect.Float32:
-	case reflect.Float64:
-		fieldVal.SetFloat(value.(float64))
-	case reflect.Complex64:
-	case reflect.Complex128:
-		fieldVal.SetComplex(value.(complex128))
-	case reflect.String:
-		fieldVal.SetString(value.(string))
-	default:
-		panic(fmt.Sprintf("Unsupported type [%s]", fieldVal.Kind().String()))
+	in := make([]reflect.Value, len(args))
+	for j, arg := range args {
+		var paramType reflect.Type
+		if t.IsVariadic() && j >= t.NumIn()-1 {
+			paramType = t.In(t.NumIn() - 1).Elem()
+		} else {
+			paramType = t.In(j)
+		}
+
+		in[j] = reflect.New(paramType).Elem()
+		if err := assignValue(in[j], reflect.ValueOf(arg), false); err != nil {
+			return nil, fmt.Errorf("argument %d of method %s: %v", j, name, err)
+		}
 	}
+
+	out := v.Method(data.Index).Call(in)
+
+	var err error
+	if n := len(out); n > 0 && out[n-1].Type() == errorType {
+		if !out[n-1].IsNil() {
+			err = out[n-1].Interface().(error)
+		}
+
+		out = out[:n-1]
+	}
+
+	results := make([]interface{}, len(out))
+	for j := range out {
+		results[j] = out[j].Interface()
+	}
+
+	return results, err
 }
@@ -1,8 +1,10 @@
 package nautilus
 
 import (
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -33,9 +35,10 @@ func TestGetType(t *testing.T) {
 	assert.Equal(t, "sampleStruct", res)
 	assert.Equal(t, "sampleStruct", res2)
 
-	assert.Panics(t, func() {
-		_ = GetType(1)
-	})
+	assert.Equal(t, "int", GetType(1))
+	assert.Equal(t, "[]*sampleStruct", GetType([]*sampleStruct{}))
+	assert.Equal(t, "Time", GetType(time.Time{}))
+	assert.Equal(t, "", GetType(nil))
 }
 
 func TestGetStructFieldsData(t *testing.T) {
@@ -76,3 +79,615 @@ func TestGetStructFieldsData(t *testing.T) {
 		assert.Nil(t, fieldsData)
 	})
 }
+
+type status string
+
+func TestPointerToValue(t *testing.T) {
+	i := 42
+	ip := &i
+	s := status("active")
+	st := sampleStruct{Exposed: 1}
+	slice := []int{1, 2}
+	m := map[string]int{"a": 1}
+	arr := [2]int{1, 2}
+
+	assert.Equal(t, 42, PointerToValue(&i))
+	assert.Equal(t, 42, PointerToValue(&ip))
+	assert.Equal(t, status("active"), PointerToValue(&s))
+	assert.Equal(t, st, PointerToValue(&st))
+	assert.Equal(t, slice, PointerToValue(&slice))
+	assert.Equal(t, m, PointerToValue(&m))
+	assert.Equal(t, arr, PointerToValue(&arr))
+	assert.Equal(t, "value", PointerToValue("value"))
+	assert.Nil(t, PointerToValue((*int)(nil)))
+	assert.Nil(t, PointerToValue(nil))
+
+	var nilPtr *int
+	assert.Nil(t, PointerToValue(&nilPtr))
+}
+
+func TestValueToPointer(t *testing.T) {
+	ptr := ValueToPointer(status("active"))
+	assert.Equal(t, status("active"), *ptr.(*status))
+
+	st := sampleStruct{Exposed: 1}
+	structPtr := ValueToPointer(st).(*sampleStruct)
+	structPtr.Exposed = 2
+	assert.Equal(t, 1, st.Exposed)
+
+	assert.Equal(t, []int{1}, *ValueToPointer([]int{1}).(*[]int))
+	assert.Nil(t, ValueToPointer(nil))
+}
+
+type fieldsStruct struct {
+	*Promoted
+	Bool      bool
+	Int       int
+	Int32     int32
+	Int64     int64
+	Uint      uint
+	Uint8     uint8
+	Float32   float32
+	Float64   float64
+	Complex64 complex64
+	String    string
+	Status    status
+	Slice     []string
+	Map       map[string]int
+	Struct    Embed
+	Pointer   *int
+	Interface interface{}
+	Error     error
+	Time      time.Time
+	private   int
+}
+
+type Promoted struct {
+	Level int
+}
+
+func TestGetFieldPointer(t *testing.T) {
+	s := fieldsStruct{Int: 1, Slice: []string{"a"}, Time: time.Unix(0, 0)}
+
+	t.Run("types", func(t *testing.T) {
+		intPtr, err := GetFieldPointer(&s, "Int")
+		assert.Nil(t, err)
+		*intPtr.(*int) = 2
+		assert.Equal(t, 2, s.Int)
+
+		slicePtr, err := GetFieldPointer(&s, "Slice")
+		assert.Nil(t, err)
+		*slicePtr.(*[]string) = append(*slicePtr.(*[]string), "b")
+		assert.Equal(t, []string{"a", "b"}, s.Slice)
+
+		fields := map[string]interface{}{
+			"Complex64": (*complex64)(nil),
+			"Status":    (*status)(nil),
+			"Map":       (*map[string]int)(nil),
+			"Struct":    (*Embed)(nil),
+			"Pointer":   (**int)(nil),
+			"Interface": (*interface{})(nil),
+			"Time":      (*time.Time)(nil),
+		}
+
+		for name, expected := range fields {
+			ptr, err := GetFieldPointer(&s, name)
+			assert.Nil(t, err)
+			assert.IsType(t, expected, ptr, name)
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, err := GetFieldPointer(s, "Int")
+		assert.EqualError(t, err, "input must be a non-nil pointer to a struct")
+
+		_, err = GetFieldPointer(&s, "Missing")
+		assert.EqualError(t, err, "field Missing not found")
+
+		_, err = GetFieldPointer(&s, "private")
+		assert.EqualError(t, err, "field private is not exported")
+
+		_, err = GetFieldPointer(&s, "Time.wall")
+		assert.EqualError(t, err, "field Time.wall is not exported")
+	})
+}
+
+func TestSetFieldValue(t *testing.T) {
+	t.Run("conversions", func(t *testing.T) {
+		s := fieldsStruct{Promoted: &Promoted{}}
+		n := 5
+		str := "pointer"
+
+		values := map[string]interface{}{
+			"Bool":      true,
+			"Int":       int8(1),
+			"Int32":     2,
+			"Int64":     3,
+			"Uint":      4,
+			"Uint8":     uint64(5),
+			"Float32":   1.5,
+			"Float64":   float32(2.5),
+			"Complex64": complex(1, 2),
+			"String":    &str,
+			"Status":    "active",
+			"Slice":     []string{"a"},
+			"Map":       map[string]int{"a": 1},
+			"Struct":    Embed{},
+			"Pointer":   n,
+			"Interface": "anything",
+			"Error":     errors.New("failed"),
+			"Time":      time.Unix(10, 0),
+			"Level":     6,
+		}
+
+		for name, value := range values {
+			assert.Nil(t, SetFieldValue(&s, name, value), name)
+		}
+
+		assert.Equal(t, fieldsStruct{
+			Promoted:  &Promoted{Level: 6},
+			Bool:      true,
+			Int:       1,
+			Int32:     2,
+			Int64:     3,
+			Uint:      4,
+			Uint8:     5,
+			Float32:   1.5,
+			Float64:   2.5,
+			Complex64: complex(1, 2),
+			String:    "pointer",
+			Status:    "active",
+			Slice:     []string{"a"},
+			Map:       map[string]int{"a": 1},
+			Struct:    Embed{},
+			Pointer:   &n,
+			Interface: "anything",
+			Error:     errors.New("failed"),
+			Time:      time.Unix(10, 0),
+		}, s)
+
+		assert.Nil(t, SetFieldValue(&s, "Slice", nil))
+		assert.Nil(t, s.Slice)
+	})
+	t.Run("errors", func(t *testing.T) {
+		s := fieldsStruct{}
+
+		assert.EqualError(t, SetFieldValue(&s, "Int", "1"), "field Int: cannot convert string to int")
+		assert.EqualError(t, SetFieldValue(&s, "String", 1), "field String: cannot convert int to string")
+		assert.EqualError(t, SetFieldValue(&s, "Uint8", 256), "field Uint8: value 256 overflows uint8")
+		assert.EqualError(t, SetFieldValue(&s, "Error", "failed"), "field Error: cannot convert string to error")
+		assert.EqualError(t, SetFieldValue(&s, "private", 1), "field private is not exported")
+		assert.EqualError(t, SetFieldValue(s, "Int", 1), "input must be a non-nil pointer to a struct")
+	})
+}
+
+type pathAddress struct {
+	City string
+}
+
+type pathCustomer struct {
+	Name    string
+	Address *pathAddress
+}
+
+type pathItem struct {
+	Price float64
+}
+
+type PathAudit struct {
+	Editor string
+}
+
+type pathOrder struct {
+	*PathAudit
+	Customer pathCustomer
+	Items    []pathItem
+	Fixed    [2]pathItem
+	Meta     map[string]pathItem
+	Refs     map[string]*pathItem
+	Counts   map[int]int
+	Extra    interface{}
+}
+
+func TestFieldPaths(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		order := pathOrder{Items: []pathItem{{}, {}, {}}}
+
+		values := map[string]interface{}{
+			"Customer.Address.City": "Tehran",
+			"Items[2].Price":        10,
+			"Items.1.Price":         5.5,
+			"Fixed[1].Price":        float32(2),
+			"Meta[a].Price":         3,
+			`Meta["b.c"].Price`:     4,
+			"Refs.x.Price":          6,
+			"Counts[1]":             7,
+			"Editor":                "admin",
+		}
+
+		for path, value := range values {
+			assert.Nil(t, SetFieldValue(&order, path, value), path)
+		}
+
+		assert.Equal(t, "Tehran", order.Customer.Address.City)
+		assert.Equal(t, []pathItem{{}, {Price: 5.5}, {Price: 10}}, order.Items)
+		assert.Equal(t, [2]pathItem{{}, {Price: 2}}, order.Fixed)
+		assert.Equal(t, map[string]pathItem{"a": {Price: 3}, "b.c": {Price: 4}}, order.Meta)
+		assert.Equal(t, map[string]*pathItem{"x": {Price: 6}}, order.Refs)
+		assert.Equal(t, map[int]int{1: 7}, order.Counts)
+		assert.Equal(t, "admin", order.Editor)
+	})
+	t.Run("get", func(t *testing.T) {
+		order := pathOrder{
+			Customer: pathCustomer{Address: &pathAddress{City: "Shiraz"}},
+			Items:    []pathItem{{Price: 1}},
+			Meta:     map[string]pathItem{"a": {Price: 2}},
+			Extra:    map[string]interface{}{"key": []int{1, 2}},
+		}
+
+		paths := map[string]interface{}{
+			"Customer.Address.City": "Shiraz",
+			"Customer.Address":      &pathAddress{City: "Shiraz"},
+			"Items[0].Price":        1.0,
+			"Meta[a].Price":         2.0,
+			"Extra.key[1]":          2,
+		}
+
+		for path, expected := range paths {
+			value, err := GetFieldValue(order, path)
+			assert.Nil(t, err, path)
+			assert.Equal(t, expected, value, path)
+		}
+
+		ptr, err := GetFieldPointer(&order, "Items[0].Price")
+		assert.Nil(t, err)
+		*ptr.(*float64) = 9
+		assert.Equal(t, 9.0, order.Items[0].Price)
+
+		order.Refs = map[string]*pathItem{"a": {Price: 3}}
+		ptr, err = GetFieldPointer(&order, "Refs[a].Price")
+		assert.Nil(t, err)
+		*ptr.(*float64) = 8
+		assert.Equal(t, 8.0, order.Refs["a"].Price)
+	})
+	t.Run("errors", func(t *testing.T) {
+		order := pathOrder{Items: []pathItem{{}}}
+
+		_, err := GetFieldValue(order, "Customer.Address.City")
+		assert.EqualError(t, err, "field Customer.Address: nil pointer")
+
+		_, err = GetFieldValue(order, "Editor")
+		assert.EqualError(t, err, "field Editor: promoted through a nil embedded pointer")
+
+		_, err = GetFieldValue(order, "Items[3].Price")
+		assert.EqualError(t, err, "field Items[3]: index 3 out of range with length 1")
+
+		_, err = GetFieldValue(order, "Items[x]")
+		assert.EqualError(t, err, `field Items[x]: invalid index "x"`)
+
+		_, err = GetFieldValue(order, "Meta[a]")
+		assert.EqualError(t, err, `field Meta[a]: key "a" not found`)
+
+		_, err = GetFieldValue(order, "Customer.Missing")
+		assert.EqualError(t, err, "field Customer.Missing not found")
+
+		_, err = GetFieldValue(order, "Customer.Name.First")
+		assert.EqualError(t, err, `field Customer.Name.First: cannot resolve "First" on string`)
+
+		_, err = GetFieldValue(order, "Customer[0]")
+		assert.EqualError(t, err, "field Customer[0]: cannot index struct nautilus.pathCustomer")
+
+		_, err = GetFieldPointer(&pathOrder{Meta: map[string]pathItem{"a": {}}}, "Meta[a].Price")
+		assert.EqualError(t, err, "field Meta[a].Price: value is not addressable")
+
+		err = SetFieldValue(&order, "Counts[x]", 1)
+		assert.EqualError(t, err, `field Counts[x]: invalid map key: cannot parse "x" as int`)
+
+		err = SetFieldValue(&order, "Extra.key", 1)
+		assert.EqualError(t, err, "field Extra: nil interface")
+
+		_, err = GetFieldPointer(&order, "Customer.Address.City")
+		assert.EqualError(t, err, "field Customer.Address: nil pointer")
+		assert.Nil(t, order.Customer.Address)
+
+		_, err = GetFieldPointer(&order, "Refs[new].Price")
+		assert.EqualError(t, err, `field Refs[new]: key "new" not found`)
+		assert.Nil(t, order.Refs)
+
+		_, err = GetFieldPointer(&order, "Editor")
+		assert.EqualError(t, err, "field Editor: promoted through a nil embedded pointer")
+		assert.Nil(t, order.PathAudit)
+
+		err = SetFieldValue(&order, "Refs[new].Missing", 1)
+		assert.EqualError(t, err, "field Refs[new].Missing not found")
+		assert.Nil(t, order.Refs)
+
+		err = SetFieldValue(&order, "Customer.Address.City", []int{1})
+		assert.NotNil(t, err)
+		assert.Nil(t, order.Customer.Address)
+
+		invalid := []string{"", ".Items", "Items.", "Items[0", "Items]", "Items[0]Price", `Meta["a]`, `Meta["a"x]`}
+		for _, path := range invalid {
+			_, err := GetFieldValue(order, path)
+			assert.NotNil(t, err, path)
+		}
+	})
+}
+
+type flatTimestamps struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type FlatBase struct {
+	ID      string `db:"id"`
+	Version int
+	secret  string
+}
+
+type FlatOther struct {
+	Version int
+	Note    string
+}
+
+type flatAddress struct {
+	City string `db:"city"`
+	Zip  string
+}
+
+type flatNode struct {
+	Value int
+	Next  *flatNode
+}
+
+type flatModel struct {
+	FlatBase
+	*FlatOther
+	flatTimestamps
+	Note    string
+	Address flatAddress
+	Billing *flatAddress
+	Node    flatNode
+	private int
+}
+
+func TestGetStructFieldsData_Options(t *testing.T) {
+	model := flatModel{
+		FlatBase:  FlatBase{ID: "1", Version: 2},
+		FlatOther: &FlatOther{Version: 3, Note: "other"},
+		Note:      "note",
+		Address:   flatAddress{City: "Tehran"},
+		Node:      flatNode{Value: 4},
+	}
+
+	paths := func(fieldsData []FieldData) []string {
+		ret := make([]string, len(fieldsData))
+		for i, fieldData := range fieldsData {
+			ret[i] = fieldData.Path
+		}
+
+		return ret
+	}
+
+	t.Run("default", func(t *testing.T) {
+		fieldsData, err := GetStructFieldsData(model)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"FlatBase", "FlatOther", "flatTimestamps", "Note", "Address", "Billing", "Node", "private"}, paths(fieldsData))
+		assert.Equal(t, []int{4}, fieldsData[4].Index)
+	})
+	t.Run("flatten embedded", func(t *testing.T) {
+		fieldsData, err := GetStructFieldsData(&model, FieldsDataOptions{FlattenEmbedded: true})
+		assert.Nil(t, err)
+
+		// Version is ambiguous and Note of FlatOther is shadowed
+		assert.Equal(t, []string{"ID", "CreatedAt", "UpdatedAt", "Note", "Address", "Billing", "Node"}, paths(fieldsData))
+		assert.Equal(t, []int{0, 0}, fieldsData[0].Index)
+		assert.Equal(t, "1", fieldsData[0].Value)
+		assert.Equal(t, reflect.StructTag(`db:"id"`), fieldsData[0].Tags)
+		assert.Equal(t, []int{2, 1}, fieldsData[2].Index)
+		assert.Equal(t, time.Time{}, fieldsData[2].Value)
+		assert.Equal(t, "note", fieldsData[3].Value)
+	})
+	t.Run("nested", func(t *testing.T) {
+		fieldsData, err := GetStructFieldsData(model, FieldsDataOptions{FlattenEmbedded: true, Nested: true})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			"ID", "CreatedAt", "UpdatedAt", "Note",
+			"Address.City", "Address.Zip", "Billing.City", "Billing.Zip",
+			"Node.Value", "Node.Next",
+		}, paths(fieldsData))
+
+		assert.Equal(t, "City", fieldsData[4].Name)
+		assert.Equal(t, []int{4, 0}, fieldsData[4].Index)
+		assert.Equal(t, "Tehran", fieldsData[4].Value)
+		assert.Equal(t, reflect.StructTag(`db:"city"`), fieldsData[4].Tags)
+
+		// Billing is nil, so its fields have no value
+		assert.Equal(t, []int{5, 0}, fieldsData[6].Index)
+		assert.Nil(t, fieldsData[6].Value)
+
+		assert.Equal(t, 4, fieldsData[8].Value)
+		assert.Equal(t, (*flatNode)(nil), fieldsData[9].Value)
+	})
+	t.Run("nested without flattening", func(t *testing.T) {
+		fieldsData, err := GetStructFieldsData(model, FieldsDataOptions{Nested: true})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			"FlatBase.ID", "FlatBase.Version",
+			"FlatOther.Version", "FlatOther.Note",
+			"Note", "Address.City", "Address.Zip", "Billing.City", "Billing.Zip",
+			"Node.Value", "Node.Next",
+		}, paths(fieldsData))
+		assert.Equal(t, 3, fieldsData[2].Value)
+	})
+	t.Run("unexported", func(t *testing.T) {
+		fieldsData, err := GetStructFieldsData(model, FieldsDataOptions{FlattenEmbedded: true, IncludeUnexported: true})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"ID", "secret", "CreatedAt", "UpdatedAt", "Note", "Address", "Billing", "Node", "private"}, paths(fieldsData))
+		assert.False(t, fieldsData[1].Exported)
+		assert.Nil(t, fieldsData[1].Value)
+		assert.Equal(t, reflect.TypeOf(0), fieldsData[8].Type)
+		assert.Nil(t, fieldsData[8].Value)
+	})
+}
+
+type FlatText struct {
+	Value string
+}
+
+func (f FlatText) MarshalText() ([]byte, error) {
+	return []byte(f.Value), nil
+}
+
+type FlatJSON struct {
+	Value string
+}
+
+func (f *FlatJSON) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + f.Value + `"`), nil
+}
+
+type flatValues struct {
+	time.Time
+	FlatText
+	*FlatJSON
+	Note string
+}
+
+func TestGetStructFieldsData_ValueStructs(t *testing.T) {
+	now := time.Now()
+	model := flatValues{Time: now, FlatText: FlatText{Value: "text"}, Note: "note"}
+
+	fieldsData, err := GetStructFieldsData(model, FieldsDataOptions{FlattenEmbedded: true})
+	assert.Nil(t, err)
+	assert.Len(t, fieldsData, 4)
+
+	assert.Equal(t, "Time", fieldsData[0].Path)
+	assert.Equal(t, now, fieldsData[0].Value)
+	assert.Equal(t, "FlatText", fieldsData[1].Path)
+	assert.Equal(t, FlatText{Value: "text"}, fieldsData[1].Value)
+	assert.Equal(t, "FlatJSON", fieldsData[2].Path)
+	assert.Equal(t, (*FlatJSON)(nil), fieldsData[2].Value)
+	assert.Equal(t, "Note", fieldsData[3].Path)
+}
+
+func TestConvertValue(t *testing.T) {
+	var i int
+	assert.Nil(t, ConvertValue(float64(42), &i))
+	assert.Equal(t, 42, i)
+
+	var s status
+	str := "active"
+	assert.Nil(t, ConvertValue(&str, &s))
+	assert.Equal(t, status("active"), s)
+
+	assert.EqualError(t, ConvertValue(1.5, &i), "cannot convert 1.5 to int without losing precision")
+	assert.EqualError(t, ConvertValue(1, i), "output must be a non-nil pointer")
+}
+
+type hookModel struct {
+	Name  string
+	Saved int
+}
+
+func (m hookModel) Validate() error {
+	if m.Name == "" {
+		return errors.New("name is required")
+	}
+
+	return nil
+}
+
+func (m *hookModel) BeforeSave(times int64) {
+	m.Saved += int(times)
+}
+
+func (m hookModel) Join(sep string, parts ...string) (string, int) {
+	result := m.Name
+	for _, part := range parts {
+		result += sep + part
+	}
+
+	return result, len(parts)
+}
+
+type validator interface {
+	Validate() error
+}
+
+func TestMethods(t *testing.T) {
+	m := &hookModel{Name: "john"}
+
+	t.Run("list", func(t *testing.T) {
+		methods := ListMethods(m)
+
+		var signatures []string
+		for _, method := range methods {
+			signatures = append(signatures, method.Name+" "+method.Signature)
+		}
+
+		assert.Equal(t, []string{
+			"BeforeSave func(int64)",
+			"Join func(string, ...string) (string, int)",
+			"Validate func() error",
+		}, signatures)
+		assert.Len(t, ListMethods(hookModel{}), 2)
+		assert.Nil(t, ListMethods(nil))
+	})
+	t.Run("has method", func(t *testing.T) {
+		assert.True(t, HasMethod(m, "BeforeSave"))
+		assert.True(t, HasMethod(*m, "Validate"))
+		assert.False(t, HasMethod(*m, "BeforeSave"))
+		assert.False(t, HasMethod(m, "Missing"))
+		assert.False(t, HasMethod(nil, "Validate"))
+	})
+	t.Run("implements interface", func(t *testing.T) {
+		assert.True(t, ImplementsInterface(m, (*validator)(nil)))
+		assert.True(t, ImplementsInterface(hookModel{}, reflect.TypeOf((*validator)(nil)).Elem()))
+		assert.False(t, ImplementsInterface(1, (*validator)(nil)))
+		assert.False(t, ImplementsInterface(m, hookModel{}))
+		assert.False(t, ImplementsInterface(nil, (*validator)(nil)))
+	})
+	t.Run("call", func(t *testing.T) {
+		results, err := CallMethod(m, "BeforeSave", 2)
+		assert.Nil(t, err)
+		assert.Empty(t, results)
+		assert.Equal(t, 2, m.Saved)
+
+		results, err = CallMethod(m, "Validate")
+		assert.Nil(t, err)
+		assert.Empty(t, results)
+
+		_, err = CallMethod(hookModel{}, "Validate")
+		assert.EqualError(t, err, "name is required")
+
+		results, err = CallMethod(*m, "Join", "-", "a", "b")
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"john-a-b", 2}, results)
+
+		results, err = CallMethod(m, "Join", ",")
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"john", 0}, results)
+	})
+	t.Run("call errors", func(t *testing.T) {
+		_, err := CallMethod(m, "Missing")
+		assert.EqualError(t, err, "method Missing of *nautilus.hookModel not found")
+
+		_, err = CallMethod(*m, "BeforeSave", 1)
+		assert.EqualError(t, err, "method BeforeSave of nautilus.hookModel has a pointer receiver")
+
+		_, err = CallMethod((*hookModel)(nil), "Validate")
+		assert.EqualError(t, err, "cannot call method Validate of nil *nautilus.hookModel")
+
+		_, err = CallMethod(nil, "Validate")
+		assert.EqualError(t, err, "cannot call method Validate of nil")
+
+		_, err = CallMethod(m, "BeforeSave")
+		assert.EqualError(t, err, "method BeforeSave expects 1 arguments, got 0")
+
+		_, err = CallMethod(m, "Join")
+		assert.EqualError(t, err, "method Join expects at least 1 arguments, got 0")
+
+		_, err = CallMethod(m, "BeforeSave", "two")
+		assert.EqualError(t, err, "argument 0 of method BeforeSave: cannot convert string to int64")
+	})
+}
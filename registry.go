@@ -0,0 +1,151 @@
+package nautilus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// TypeRegistry maps names to types, so values could be instantiated by
+// their type names, e.g. for decoding polymorphic data which contains the
+// type name of each value. Types are registered by their qualified names
+// (see TypeName) or custom names. It is safe for concurrent use.
+type TypeRegistry struct {
+	mutex sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}
+
+// DefaultTypeRegistry is the registry used by RegisterType and NewType
+var DefaultTypeRegistry = NewTypeRegistry()
+
+// NewTypeRegistry returns a new empty TypeRegistry
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		types: make(map[string]reflect.Type),
+		names: make(map[reflect.Type]string),
+	}
+}
+
+// RegisterType registers types of given values in the default registry
+func RegisterType(values ...interface{}) error {
+	return DefaultTypeRegistry.Register(values...)
+}
+
+// NewType returns a pointer to a new zero value of the type registered in
+// the default registry with given name
+func NewType(name string) (interface{}, error) {
+	return DefaultTypeRegistry.New(name)
+}
+
+// Register registers types of given values by their qualified names.
+// Pointers are dereferenced, so registering a User or a *User both
+// register the User type.
+func (r *TypeRegistry) Register(values ...interface{}) error {
+	for _, v := range values {
+		t, err := registryType(v)
+		if err != nil {
+			return err
+		}
+
+		if err := r.register(formatType(t, true), t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RegisterAs registers the type of given value by given name, which is
+// useful for short or stable names that do not change when the type is
+// moved to another package. A type could have several names, but a name
+// could not be registered for different types.
+func (r *TypeRegistry) RegisterAs(name string, v interface{}) error {
+	t, err := registryType(v)
+	if err != nil {
+		return err
+	}
+
+	return r.register(name, t)
+}
+
+func (r *TypeRegistry) register(name string, t reflect.Type) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if registered, ok := r.types[name]; ok && registered != t {
+		return fmt.Errorf("type name %q is already registered for %s", name, formatType(registered, true))
+	}
+
+	r.types[name] = t
+	if _, ok := r.names[t]; !ok {
+		r.names[t] = name
+	}
+
+	return nil
+}
+
+// Lookup returns the type registered with given name
+func (r *TypeRegistry) Lookup(name string) (reflect.Type, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// NameOf returns the name of the type of given value (or the type it
+// points to), which is the first name the type is registered with
+func (r *TypeRegistry) NameOf(v interface{}) (string, bool) {
+	t, err := registryType(v)
+	if err != nil {
+		return "", false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	name, ok := r.names[t]
+	return name, ok
+}
+
+// New returns a pointer to a new zero value of the type registered with
+// given name, e.g. a *User, which could be used as decoding target
+func (r *TypeRegistry) New(name string) (interface{}, error) {
+	t, ok := r.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("type %q is not registered", name)
+	}
+
+	return reflect.New(t).Interface(), nil
+}
+
+// Names returns all registered names in sorted order
+func (r *TypeRegistry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// registryType returns the type of given value with pointers dereferenced
+func registryType(v interface{}) (reflect.Type, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, errors.New("cannot register type of nil")
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t, nil
+}
@@ -0,0 +1,72 @@
+package nautilus
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type registryCircle struct {
+	Radius float64
+}
+
+type registrySquare struct {
+	Side float64
+}
+
+func TestTypeRegistry(t *testing.T) {
+	registry := NewTypeRegistry()
+
+	assert.Nil(t, registry.Register(registryCircle{}, &registrySquare{}))
+	assert.Nil(t, registry.RegisterAs("circle", registryCircle{}))
+	assert.Nil(t, registry.RegisterAs("circle", &registryCircle{}))
+	assert.EqualError(t, registry.RegisterAs("circle", registrySquare{}),
+		`type name "circle" is already registered for github.com/Kamva/nautilus.registryCircle`)
+	assert.EqualError(t, registry.Register(nil), "cannot register type of nil")
+
+	assert.Equal(t, []string{
+		"circle",
+		"github.com/Kamva/nautilus.registryCircle",
+		"github.com/Kamva/nautilus.registrySquare",
+	}, registry.Names())
+
+	name, ok := registry.NameOf(&registrySquare{})
+	assert.True(t, ok)
+	assert.Equal(t, "github.com/Kamva/nautilus.registrySquare", name)
+
+	_, ok = registry.NameOf(1)
+	assert.False(t, ok)
+
+	v, err := registry.New("circle")
+	assert.Nil(t, err)
+	assert.Equal(t, &registryCircle{}, v)
+
+	_, err = registry.New("triangle")
+	assert.EqualError(t, err, `type "triangle" is not registered`)
+}
+
+func TestTypeRegistryDecoding(t *testing.T) {
+	registry := NewTypeRegistry()
+	assert.Nil(t, registry.RegisterAs("circle", registryCircle{}))
+	assert.Nil(t, registry.RegisterAs("square", registrySquare{}))
+
+	data := `[{"type":"circle","value":{"Radius":1}},{"type":"square","value":{"Side":2}}]`
+
+	var items []struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(data), &items))
+
+	var shapes []interface{}
+	for _, item := range items {
+		shape, err := registry.New(item.Type)
+		assert.Nil(t, err)
+		assert.Nil(t, json.Unmarshal(item.Value, shape))
+
+		shapes = append(shapes, shape)
+	}
+
+	assert.Equal(t, []interface{}{&registryCircle{Radius: 1}, &registrySquare{Side: 2}}, shapes)
+}
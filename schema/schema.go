@@ -0,0 +1,817 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Kamva/nautilus/types"
+)
+
+// Violation describes a single failed schema constraint
+type Violation struct {
+	// Location is a JSON pointer to the invalid value in validated document
+	Location string
+
+	// SchemaLocation is a JSON pointer to the failed subschema
+	SchemaLocation string
+
+	// Keyword is the failed schema keyword, such as "required" or "type"
+	Keyword string
+
+	// Message is a human readable description of the violation
+	Message string
+}
+
+// String converts violation into a readable string
+func (v Violation) String() string {
+	location := v.Location
+	if location == "" {
+		location = "/"
+	}
+
+	return fmt.Sprintf("%s: %s", location, v.Message)
+}
+
+// Violations is list of all violations of a document. It implements error
+// interface, so it could be returned as validation error.
+type Violations []Violation
+
+// Error implements error interface
+func (v Violations) Error() string {
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = violation.String()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Schema is a compiled JSON schema which is ready for validating documents.
+// It supports a subset of draft 2020-12 containing type, enum, const,
+// required, properties, additionalProperties, pattern, minLength,
+// maxLength, minimum, maximum, exclusiveMinimum, exclusiveMaximum, items,
+// minItems, maxItems, allOf, anyOf, oneOf and $ref within the document.
+type Schema struct {
+	root  *node
+	nodes map[string]*node
+	doc   interface{}
+}
+
+type node struct {
+	location string
+
+	boolean *bool
+	ref     string
+	refNode *node
+
+	types      []string
+	enum       []interface{}
+	hasEnum    bool
+	constValue interface{}
+	hasConst   bool
+
+	required             []string
+	properties           map[string]*node
+	additionalProperties *node
+
+	pattern   *regexp.Regexp
+	minLength *int
+	maxLength *int
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+
+	items    *node
+	minItems *int
+	maxItems *int
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+}
+
+var validTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// Compile compiles given schema document
+func Compile(doc types.JSONMap) (*Schema, error) {
+	return compile(map[string]interface{}(doc))
+}
+
+// CompileJSON compiles given json encoded schema document
+func CompileJSON(data []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return compile(doc)
+}
+
+// MustCompile is like Compile but panics if the schema is invalid
+func MustCompile(doc types.JSONMap) *Schema {
+	s, err := Compile(doc)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// Validate validates given JSONMap against schema document
+func Validate(schema types.JSONMap, doc types.JSONMap) error {
+	s, err := Compile(schema)
+	if err != nil {
+		return err
+	}
+
+	return s.Validate(doc)
+}
+
+func compile(doc interface{}) (*Schema, error) {
+	s := &Schema{nodes: make(map[string]*node), doc: doc}
+
+	root, err := s.compileNode(doc, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.root = root
+
+	if err := s.resolveRefs(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Validate validates given value and returns Violations error containing
+// all failed constraints, or nil if value is valid. Value could be a
+// JSONMap or any value decoded from json.
+func (s *Schema) Validate(v interface{}) error {
+	violations := s.root.validate(v, "", nil)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return violations
+}
+
+// resolveRefs links all compiled $ref keywords to their target nodes. It
+// compiles targets that are not compiled yet, so it loops until no new
+// node has been added.
+func (s *Schema) resolveRefs() error {
+	for {
+		var pending []*node
+		for _, n := range s.nodes {
+			if n.ref != "" && n.refNode == nil {
+				pending = append(pending, n)
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		for _, n := range pending {
+			target, err := s.resolveRef(n.ref)
+			if err != nil {
+				return fmt.Errorf("%s/$ref: %v", n.location, err)
+			}
+
+			n.refNode = target
+		}
+	}
+}
+
+func (s *Schema) resolveRef(ref string) (*node, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only references within the document are supported, got %q", ref)
+	}
+
+	pointer := strings.TrimPrefix(ref, "#")
+	if n, ok := s.nodes[pointer]; ok {
+		return n, nil
+	}
+
+	target, err := resolvePointer(s.doc, pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.compileNode(target, pointer)
+}
+
+// resolvePointer finds value of given JSON pointer inside doc
+func resolvePointer(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return doc, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	current := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+
+		if object, ok := asObject(current); ok {
+			value, ok := object[token]
+			if !ok {
+				return nil, fmt.Errorf("JSON pointer %q not found", pointer)
+			}
+
+			current = value
+			continue
+		}
+
+		if array, ok := asArray(current); ok {
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("JSON pointer %q not found", pointer)
+			}
+
+			current = array[index]
+			continue
+		}
+
+		return nil, fmt.Errorf("JSON pointer %q not found", pointer)
+	}
+
+	return current, nil
+}
+
+func (s *Schema) compileNode(raw interface{}, location string) (*node, error) {
+	n := &node{location: location}
+	s.nodes[location] = n
+
+	if b, ok := raw.(bool); ok {
+		n.boolean = &b
+		return n, nil
+	}
+
+	object, ok := asObject(raw)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or boolean", displayPointer(location))
+	}
+
+	var err error
+	for keyword, value := range object {
+		keywordLocation := location + "/" + escapePointer(keyword)
+
+		switch keyword {
+		case "$ref":
+			ref, ok := value.(string)
+			if !ok {
+				return nil, keywordError(keywordLocation, "must be a string")
+			}
+			n.ref = ref
+		case "type":
+			n.types, err = compileTypes(value, keywordLocation)
+		case "enum":
+			n.enum, ok = asArray(value)
+			if !ok {
+				return nil, keywordError(keywordLocation, "must be an array")
+			}
+			n.hasEnum = true
+		case "const":
+			n.constValue, n.hasConst = value, true
+		case "required":
+			n.required, err = compileStrings(value, keywordLocation)
+		case "properties":
+			n.properties, err = s.compileProperties(value, keywordLocation)
+		case "additionalProperties":
+			n.additionalProperties, err = s.compileNode(value, keywordLocation)
+		case "$defs", "definitions":
+			err = s.compileDefinitions(value, keywordLocation)
+		case "pattern":
+			n.pattern, err = compilePattern(value, keywordLocation)
+		case "minLength":
+			n.minLength, err = compileCount(value, keywordLocation)
+		case "maxLength":
+			n.maxLength, err = compileCount(value, keywordLocation)
+		case "minimum":
+			n.minimum, err = compileNumber(value, keywordLocation)
+		case "maximum":
+			n.maximum, err = compileNumber(value, keywordLocation)
+		case "exclusiveMinimum":
+			n.exclusiveMinimum, err = compileNumber(value, keywordLocation)
+		case "exclusiveMaximum":
+			n.exclusiveMaximum, err = compileNumber(value, keywordLocation)
+		case "items":
+			n.items, err = s.compileNode(value, keywordLocation)
+		case "minItems":
+			n.minItems, err = compileCount(value, keywordLocation)
+		case "maxItems":
+			n.maxItems, err = compileCount(value, keywordLocation)
+		case "allOf":
+			n.allOf, err = s.compileList(value, keywordLocation)
+		case "anyOf":
+			n.anyOf, err = s.compileList(value, keywordLocation)
+		case "oneOf":
+			n.oneOf, err = s.compileList(value, keywordLocation)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+func (s *Schema) compileProperties(value interface{}, location string) (map[string]*node, error) {
+	object, ok := asObject(value)
+	if !ok {
+		return nil, keywordError(location, "must be an object")
+	}
+
+	properties := make(map[string]*node, len(object))
+	for name, raw := range object {
+		n, err := s.compileNode(raw, location+"/"+escapePointer(name))
+		if err != nil {
+			return nil, err
+		}
+
+		properties[name] = n
+	}
+
+	return properties, nil
+}
+
+func (s *Schema) compileDefinitions(value interface{}, location string) error {
+	_, err := s.compileProperties(value, location)
+	return err
+}
+
+func (s *Schema) compileList(value interface{}, location string) ([]*node, error) {
+	array, ok := asArray(value)
+	if !ok || len(array) == 0 {
+		return nil, keywordError(location, "must be a non-empty array")
+	}
+
+	nodes := make([]*node, len(array))
+	for i, raw := range array {
+		n, err := s.compileNode(raw, location+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+
+		nodes[i] = n
+	}
+
+	return nodes, nil
+}
+
+func compileTypes(value interface{}, location string) ([]string, error) {
+	var names []string
+	if name, ok := value.(string); ok {
+		names = []string{name}
+	} else {
+		var err error
+		if names, err = compileStrings(value, location); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range names {
+		if !validTypes[name] {
+			return nil, keywordError(location, fmt.Sprintf("unknown type %q", name))
+		}
+	}
+
+	return names, nil
+}
+
+func compileStrings(value interface{}, location string) ([]string, error) {
+	array, ok := asArray(value)
+	if !ok {
+		return nil, keywordError(location, "must be an array of strings")
+	}
+
+	result := make([]string, len(array))
+	for i, item := range array {
+		str, ok := item.(string)
+		if !ok {
+			return nil, keywordError(location, "must be an array of strings")
+		}
+
+		result[i] = str
+	}
+
+	return result, nil
+}
+
+func compilePattern(value interface{}, location string) (*regexp.Regexp, error) {
+	pattern, ok := value.(string)
+	if !ok {
+		return nil, keywordError(location, "must be a string")
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, keywordError(location, err.Error())
+	}
+
+	return regex, nil
+}
+
+func compileNumber(value interface{}, location string) (*float64, error) {
+	f, ok := asNumber(value)
+	if !ok {
+		return nil, keywordError(location, "must be a number")
+	}
+
+	return &f, nil
+}
+
+func compileCount(value interface{}, location string) (*int, error) {
+	f, ok := asNumber(value)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, keywordError(location, "must be a non-negative integer")
+	}
+
+	count := int(f)
+	return &count, nil
+}
+
+func keywordError(location string, message string) error {
+	return errors.New(displayPointer(location) + ": " + message)
+}
+
+// validate checks given value against the node. visited contains nodes
+// that were entered through $ref for the current value to stop infinite
+// recursion of self referencing schemas.
+func (n *node) validate(v interface{}, location string, visited map[*node]bool) Violations {
+	if n.boolean != nil {
+		if *n.boolean {
+			return nil
+		}
+
+		return Violations{n.violation(location, "", "value is not allowed")}
+	}
+
+	var violations Violations
+
+	if n.refNode != nil && !visited[n.refNode] {
+		refVisited := make(map[*node]bool, len(visited)+1)
+		for k := range visited {
+			refVisited[k] = true
+		}
+		refVisited[n.refNode] = true
+
+		violations = append(violations, n.refNode.validate(v, location, refVisited)...)
+	}
+
+	if len(n.types) > 0 && !matchesAnyType(v, n.types) {
+		message := fmt.Sprintf("expected %s, got %s", strings.Join(n.types, " or "), typeOf(v))
+		return append(violations, n.violation(location, "type", message))
+	}
+
+	if n.hasEnum && !containsValue(n.enum, v) {
+		violations = append(violations, n.violation(location, "enum", "value must be one of the enumerated values"))
+	}
+
+	if n.hasConst && !equal(n.constValue, v) {
+		violations = append(violations, n.violation(location, "const", "value must be equal to the constant"))
+	}
+
+	if object, ok := asObject(v); ok {
+		violations = append(violations, n.validateObject(object, location)...)
+	}
+
+	if array, ok := asArray(v); ok {
+		violations = append(violations, n.validateArray(array, location)...)
+	}
+
+	if str, ok := v.(string); ok {
+		violations = append(violations, n.validateString(str, location)...)
+	}
+
+	if number, ok := asNumber(v); ok {
+		violations = append(violations, n.validateNumber(number, location)...)
+	}
+
+	violations = append(violations, n.validateCombinations(v, location, visited)...)
+
+	return violations
+}
+
+func (n *node) validateObject(object map[string]interface{}, location string) Violations {
+	var violations Violations
+
+	for _, name := range n.required {
+		if _, ok := object[name]; !ok {
+			violations = append(violations, n.violation(location, "required", fmt.Sprintf("property %q is required", name)))
+		}
+	}
+
+	for _, name := range sortedKeys(object) {
+		propertyLocation := location + "/" + escapePointer(name)
+
+		if property, ok := n.properties[name]; ok {
+			violations = append(violations, property.validate(object[name], propertyLocation, nil)...)
+		} else if n.additionalProperties != nil {
+			violations = append(violations, n.additionalProperties.validate(object[name], propertyLocation, nil)...)
+		}
+	}
+
+	return violations
+}
+
+func (n *node) validateArray(array []interface{}, location string) Violations {
+	var violations Violations
+
+	if n.minItems != nil && len(array) < *n.minItems {
+		violations = append(violations, n.violation(location, "minItems", fmt.Sprintf("must contain at least %d items", *n.minItems)))
+	}
+
+	if n.maxItems != nil && len(array) > *n.maxItems {
+		violations = append(violations, n.violation(location, "maxItems", fmt.Sprintf("must contain at most %d items", *n.maxItems)))
+	}
+
+	if n.items != nil {
+		for i, item := range array {
+			violations = append(violations, n.items.validate(item, location+"/"+strconv.Itoa(i), nil)...)
+		}
+	}
+
+	return violations
+}
+
+func (n *node) validateString(str string, location string) Violations {
+	var violations Violations
+	length := utf8.RuneCountInString(str)
+
+	if n.minLength != nil && length < *n.minLength {
+		violations = append(violations, n.violation(location, "minLength", fmt.Sprintf("length must be at least %d", *n.minLength)))
+	}
+
+	if n.maxLength != nil && length > *n.maxLength {
+		violations = append(violations, n.violation(location, "maxLength", fmt.Sprintf("length must be at most %d", *n.maxLength)))
+	}
+
+	if n.pattern != nil && !n.pattern.MatchString(str) {
+		violations = append(violations, n.violation(location, "pattern", fmt.Sprintf("must match pattern %q", n.pattern.String())))
+	}
+
+	return violations
+}
+
+func (n *node) validateNumber(number float64, location string) Violations {
+	var violations Violations
+
+	if n.minimum != nil && number < *n.minimum {
+		violations = append(violations, n.violation(location, "minimum", fmt.Sprintf("must be greater than or equal to %v", *n.minimum)))
+	}
+
+	if n.maximum != nil && number > *n.maximum {
+		violations = append(violations, n.violation(location, "maximum", fmt.Sprintf("must be less than or equal to %v", *n.maximum)))
+	}
+
+	if n.exclusiveMinimum != nil && number <= *n.exclusiveMinimum {
+		violations = append(violations, n.violation(location, "exclusiveMinimum", fmt.Sprintf("must be greater than %v", *n.exclusiveMinimum)))
+	}
+
+	if n.exclusiveMaximum != nil && number >= *n.exclusiveMaximum {
+		violations = append(violations, n.violation(location, "exclusiveMaximum", fmt.Sprintf("must be less than %v", *n.exclusiveMaximum)))
+	}
+
+	return violations
+}
+
+func (n *node) validateCombinations(v interface{}, location string, visited map[*node]bool) Violations {
+	var violations Violations
+
+	for _, sub := range n.allOf {
+		violations = append(violations, sub.validate(v, location, visited)...)
+	}
+
+	if len(n.anyOf) > 0 && countMatches(n.anyOf, v, location, visited) == 0 {
+		violations = append(violations, n.violation(location, "anyOf", "must match at least one schema of anyOf"))
+	}
+
+	if len(n.oneOf) > 0 {
+		if matches := countMatches(n.oneOf, v, location, visited); matches != 1 {
+			message := fmt.Sprintf("must match exactly one schema of oneOf, matched %d", matches)
+			violations = append(violations, n.violation(location, "oneOf", message))
+		}
+	}
+
+	return violations
+}
+
+func (n *node) violation(location string, keyword string, message string) Violation {
+	schemaLocation := n.location
+	if keyword != "" {
+		schemaLocation += "/" + keyword
+	}
+
+	return Violation{Location: location, SchemaLocation: schemaLocation, Keyword: keyword, Message: message}
+}
+
+func countMatches(nodes []*node, v interface{}, location string, visited map[*node]bool) int {
+	matches := 0
+	for _, sub := range nodes {
+		if len(sub.validate(v, location, visited)) == 0 {
+			matches++
+		}
+	}
+
+	return matches
+}
+
+func matchesAnyType(v interface{}, names []string) bool {
+	actual := typeOf(v)
+	for _, name := range names {
+		if name == actual {
+			return true
+		}
+
+		if name == "number" && actual == "integer" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// typeOf returns JSON type name of given value. Numbers without fraction
+// are reported as integer.
+func typeOf(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+
+	if _, ok := v.(bool); ok {
+		return "boolean"
+	}
+
+	if _, ok := v.(string); ok {
+		return "string"
+	}
+
+	if number, ok := asNumber(v); ok {
+		if number == math.Trunc(number) && !math.IsInf(number, 0) {
+			return "integer"
+		}
+
+		return "number"
+	}
+
+	if _, ok := asObject(v); ok {
+		return "object"
+	}
+
+	if _, ok := asArray(v); ok {
+		return "array"
+	}
+
+	return reflect.TypeOf(v).String()
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if equal(value, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// equal compares two json values, numbers are compared by their value
+// regardless of their go type
+func equal(a interface{}, b interface{}) bool {
+	if na, ok := asNumber(a); ok {
+		nb, ok := asNumber(b)
+		return ok && na == nb
+	}
+
+	if oa, ok := asObject(a); ok {
+		ob, ok := asObject(b)
+		if !ok || len(oa) != len(ob) {
+			return false
+		}
+
+		for key, value := range oa {
+			other, ok := ob[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if aa, ok := asArray(a); ok {
+		ab, ok := asArray(b)
+		if !ok || len(aa) != len(ab) {
+			return false
+		}
+
+		for i := range aa {
+			if !equal(aa[i], ab[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return a == b
+}
+
+func asObject(v interface{}) (map[string]interface{}, bool) {
+	switch value := v.(type) {
+	case types.JSONMap:
+		return value, true
+	case map[string]interface{}:
+		return value, true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	result := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		result[iter.Key().String()] = iter.Value().Interface()
+	}
+
+	return result, true
+}
+
+func asArray(v interface{}) ([]interface{}, bool) {
+	if value, ok := v.([]interface{}); ok {
+		return value, true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	result := make([]interface{}, rv.Len())
+	for i := range result {
+		result[i] = rv.Index(i).Interface()
+	}
+
+	return result, true
+}
+
+func asNumber(v interface{}) (float64, bool) {
+	if number, ok := v.(json.Number); ok {
+		f, err := number.Float64()
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func displayPointer(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+
+	return pointer
+}
@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/Kamva/nautilus/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var userSchema = types.JSONMap{
+	"type":     "object",
+	"required": []interface{}{"name", "email", "address"},
+	"properties": map[string]interface{}{
+		"name":  map[string]interface{}{"type": "string", "minLength": 3, "maxLength": 10},
+		"email": map[string]interface{}{"type": "string", "pattern": "^[^@]+@[^@]+$"},
+		"age":   map[string]interface{}{"type": "integer", "minimum": 18, "exclusiveMaximum": 150},
+		"role":  map[string]interface{}{"enum": []interface{}{"admin", "user"}},
+		"tags": map[string]interface{}{
+			"type":     "array",
+			"items":    map[string]interface{}{"type": "string"},
+			"maxItems": 2,
+		},
+		"address": map[string]interface{}{"$ref": "#/$defs/address"},
+		"contact": map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "integer"},
+			},
+		},
+		"score": map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "number", "maximum": 10},
+				map[string]interface{}{"type": "null"},
+			},
+		},
+	},
+	"additionalProperties": false,
+	"$defs": map[string]interface{}{
+		"address": map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"city"},
+			"properties": map[string]interface{}{
+				"city":   map[string]interface{}{"type": "string"},
+				"parent": map[string]interface{}{"$ref": "#/$defs/address"},
+			},
+		},
+	},
+}
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := Compile(userSchema)
+	assert.Nil(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		doc := types.JSONMap{
+			"name":    "John",
+			"email":   "john@example.com",
+			"age":     float64(30),
+			"role":    "admin",
+			"tags":    []interface{}{"a"},
+			"address": types.JSONMap{"city": "Tehran", "parent": map[string]interface{}{"city": "Iran"}},
+			"contact": 12,
+			"score":   nil,
+		}
+
+		assert.Nil(t, s.Validate(doc))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		doc := types.JSONMap{
+			"name":    "Jo",
+			"email":   "invalid",
+			"age":     17.5,
+			"role":    "guest",
+			"tags":    []interface{}{"a", 1, "c"},
+			"address": map[string]interface{}{"parent": map[string]interface{}{"city": 1}},
+			"contact": true,
+			"score":   float64(11),
+			"unknown": "value",
+		}
+
+		err := s.Validate(doc)
+		violations, ok := err.(Violations)
+
+		assert.True(t, ok)
+
+		locations := make(map[string]string)
+		for _, violation := range violations {
+			locations[violation.Location+" "+violation.Keyword] = violation.SchemaLocation
+		}
+
+		assert.Equal(t, map[string]string{
+			"/address required":         "/$defs/address/required",
+			"/address/parent/city type": "/$defs/address/properties/city/type",
+			"/age type":                 "/properties/age/type",
+			"/contact oneOf":            "/properties/contact/oneOf",
+			"/email pattern":            "/properties/email/pattern",
+			"/name minLength":           "/properties/name/minLength",
+			"/role enum":                "/properties/role/enum",
+			"/score anyOf":              "/properties/score/anyOf",
+			"/tags maxItems":            "/properties/tags/maxItems",
+			"/tags/1 type":              "/properties/tags/items/type",
+			"/unknown ":                 "/additionalProperties",
+		}, locations)
+	})
+	t.Run("root", func(t *testing.T) {
+		err := s.Validate([]interface{}{})
+
+		assert.EqualError(t, err, "/: expected object, got array")
+	})
+	t.Run("required", func(t *testing.T) {
+		err := s.Validate(types.JSONMap{"name": "John"})
+
+		assert.EqualError(t, err, `/: property "email" is required; /: property "address" is required`)
+	})
+}
+
+func TestCompile(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		s, err := CompileJSON([]byte(`{"type": "object", "properties": {"id": {"const": 1}}}`))
+
+		assert.Nil(t, err)
+		assert.Nil(t, s.Validate(map[string]interface{}{"id": 1}))
+		assert.NotNil(t, s.Validate(map[string]interface{}{"id": 2}))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []types.JSONMap{
+			{"type": "unknown"},
+			{"required": "name"},
+			{"pattern": "("},
+			{"minLength": -1},
+			{"properties": map[string]interface{}{"name": 1}},
+			{"$ref": "#/$defs/missing"},
+			{"$ref": "http://example.com/schema.json"},
+			{"oneOf": []interface{}{}},
+		}
+
+		for _, doc := range invalid {
+			_, err := Compile(doc)
+			assert.NotNil(t, err, "%v", doc)
+		}
+	})
+	t.Run("recursive root reference", func(t *testing.T) {
+		s, err := Compile(types.JSONMap{
+			"type":       "object",
+			"properties": map[string]interface{}{"child": map[string]interface{}{"$ref": "#"}},
+		})
+
+		assert.Nil(t, err)
+		assert.Nil(t, s.Validate(types.JSONMap{"child": types.JSONMap{"child": types.JSONMap{}}}))
+		assert.EqualError(t, s.Validate(types.JSONMap{"child": types.JSONMap{"child": 1}}), "/child/child: expected object, got integer")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	err := Validate(types.JSONMap{"type": "string"}, types.JSONMap{})
+
+	assert.EqualError(t, err, "/: expected string, got object")
+}
@@ -0,0 +1,125 @@
+package nautilus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Source is a provider of configuration values, such as process
+// environment, .env files or secret files
+type Source interface {
+	// Lookup returns value of given key and whether it is present
+	Lookup(key string) (string, bool)
+}
+
+// SourceFunc is an adapter to use ordinary functions as Source
+type SourceFunc func(key string) (string, bool)
+
+// Lookup calls f(key)
+func (f SourceFunc) Lookup(key string) (string, bool) {
+	return f(key)
+}
+
+// MapSource is a Source backed by a map, for example the parsed content
+// of a .env file
+type MapSource map[string]string
+
+// Lookup returns value of given key in the map
+func (m MapSource) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// EnvSource returns a Source of process environment variables
+func EnvSource() Source {
+	return SourceFunc(os.LookupEnv)
+}
+
+// SecretsDirSource returns a Source that reads each key from a file with
+// the same name in given directory, which is how Docker and Kubernetes
+// mount secrets (e.g. /run/secrets/DB_PASSWORD). If there is no file with
+// the exact key name, its lower case name is tried. Trailing new lines of
+// the file content are trimmed.
+func SecretsDirSource(dir string) Source {
+	return SourceFunc(func(key string) (string, bool) {
+		if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+			return "", false
+		}
+
+		for _, name := range []string{key, strings.ToLower(key)} {
+			data, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err == nil {
+				return strings.TrimRight(string(data), "\r\n"), true
+			}
+		}
+
+		return "", false
+	})
+}
+
+// LayeredSource looks up keys in its sources in order, so sources that
+// come first take precedence. Similar to GetEnv, empty values are
+// considered not present and lookup continues with the next source.
+type LayeredSource []Source
+
+// Lookup returns the first non-empty value of given key in sources
+func (l LayeredSource) Lookup(key string) (string, bool) {
+	for _, source := range l {
+		if value, ok := source.Lookup(key); ok && value != "" {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// NewLayeredSource returns the standard layered configuration source. The
+// precedence is process environment, then secret files of given directory
+// (skipped if it is empty) and then given .env files in order. Missing .env
+// files are ignored, so the same setup could be used in all environments.
+func NewLayeredSource(secretsDir string, dotEnvFiles ...string) (LayeredSource, error) {
+	sources := LayeredSource{EnvSource()}
+
+	if secretsDir != "" {
+		sources = append(sources, SecretsDirSource(secretsDir))
+	}
+
+	for _, path := range dotEnvFiles {
+		source, err := LoadDotEnv(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// GetEnvFrom is like GetEnv but looks up the key in given source
+func GetEnvFrom(source Source, key string, fallback string) string {
+	value, ok := source.Lookup(key)
+
+	if !ok || len(value) == 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// LoadEnvFrom is like LoadEnv but reads variables from given source
+func LoadEnvFrom(source Source, v interface{}) error {
+	return LoadEnvFromWithPrefix(source, "", v)
+}
+
+// LoadEnvFromWithPrefix is like LoadEnvWithPrefix but reads variables from
+// given source
+func LoadEnvFromWithPrefix(source Source, prefix string, v interface{}) error {
+	return loadEnv(source, prefix, v)
+}
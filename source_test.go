@@ -0,0 +1,74 @@
+package nautilus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsDirSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nautilus-secrets")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "DB_PASSWORD"), []byte("secret\n"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "api_key"), []byte("key"), 0600))
+
+	source := SecretsDirSource(dir)
+
+	value, ok := source.Lookup("DB_PASSWORD")
+	assert.True(t, ok)
+	assert.Equal(t, "secret", value)
+
+	value, ok = source.Lookup("API_KEY")
+	assert.True(t, ok)
+	assert.Equal(t, "key", value)
+
+	_, ok = source.Lookup("MISSING")
+	assert.False(t, ok)
+
+	_, ok = source.Lookup("../DB_PASSWORD")
+	assert.False(t, ok)
+}
+
+func TestNewLayeredSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nautilus-config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	secrets := filepath.Join(dir, "secrets")
+	assert.Nil(t, os.Mkdir(secrets, 0700))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(secrets, "LAYER_SECRET"), []byte("from-secret"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(secrets, "LAYER_ENV"), []byte("from-secret"), 0600))
+
+	dotEnv := filepath.Join(dir, ".env")
+	content := "LAYER_ENV=from-file\nLAYER_SECRET=from-file\nLAYER_FILE=from-file\nLAYER_EMPTY=from-file\nLAYER_PORT=8080\n"
+	assert.Nil(t, ioutil.WriteFile(dotEnv, []byte(content), 0600))
+
+	defer setEnvs(map[string]string{"LAYER_ENV": "from-env", "LAYER_EMPTY": ""})()
+
+	source, err := NewLayeredSource(secrets, dotEnv, filepath.Join(dir, ".env.local"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "from-env", GetEnvFrom(source, "LAYER_ENV", ""))
+	assert.Equal(t, "from-secret", GetEnvFrom(source, "LAYER_SECRET", ""))
+	assert.Equal(t, "from-file", GetEnvFrom(source, "LAYER_FILE", ""))
+	assert.Equal(t, "from-file", GetEnvFrom(source, "LAYER_EMPTY", ""))
+	assert.Equal(t, "fallback", GetEnvFrom(source, "LAYER_MISSING", "fallback"))
+
+	var config struct {
+		Env  string `env:"LAYER_ENV"`
+		Port int    `env:"LAYER_PORT"`
+	}
+
+	assert.Nil(t, LoadEnvFrom(source, &config))
+	assert.Equal(t, "from-env", config.Env)
+	assert.Equal(t, 8080, config.Port)
+
+	assert.Nil(t, ioutil.WriteFile(dotEnv, []byte("INVALID"), 0600))
+	_, err = NewLayeredSource("", dotEnv)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,165 @@
+package nautilus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tag is a parsed struct tag value such as "name,omitempty,min=3", which
+// is a name followed by comma separated options
+type Tag struct {
+	Name    string
+	Options []TagOption
+}
+
+// TagOption is a single option of a tag, which is either a flag such as
+// "omitempty" or a key value pair such as "min=3"
+type TagOption struct {
+	Key      string
+	Value    string
+	HasValue bool
+}
+
+// HasOption checks whether the tag has an option with given key
+func (t Tag) HasOption(key string) bool {
+	_, ok := t.lookup(key)
+	return ok
+}
+
+// Option returns value of the first option with given key and whether the
+// option exists
+func (t Tag) Option(key string) (string, bool) {
+	option, ok := t.lookup(key)
+	return option.Value, ok
+}
+
+func (t Tag) lookup(key string) (TagOption, bool) {
+	for _, option := range t.Options {
+		if option.Key == key {
+			return option, true
+		}
+	}
+
+	return TagOption{}, false
+}
+
+// ParseTag parses given tag value into its name and options, keeping the
+// order of options. Name and options are separated by commas and option
+// values by "=". Values could be double quoted to contain commas and "=",
+// e.g. `pattern="a,b\"c"`. Only `\"` and `\\` are unescaped, both inside
+// and outside of quotes, so other backslashes such as the ones in regular
+// expressions like `pattern=\d+` are kept intact.
+//
+// Malformed tags, such as empty options, unterminated quotes or
+// characters after a closing quote, result in an error.
+func ParseTag(tag string) (Tag, error) {
+	p := tagParser{tag: tag}
+
+	name, err := p.token(false)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	result := Tag{Name: name}
+
+	for p.pos < len(tag) {
+		// Skipping the comma
+		p.pos++
+
+		start := p.pos
+		key, err := p.token(true)
+		if err != nil {
+			return Tag{}, err
+		}
+
+		if key == "" {
+			return Tag{}, p.errorf(start, "empty option")
+		}
+
+		option := TagOption{Key: key}
+
+		if p.pos < len(tag) && tag[p.pos] == '=' {
+			p.pos++
+
+			if option.Value, err = p.token(false); err != nil {
+				return Tag{}, err
+			}
+
+			option.HasValue = true
+		}
+
+		result.Options = append(result.Options, option)
+	}
+
+	return result, nil
+}
+
+// tagParser is the state of parsing a tag value
+type tagParser struct {
+	tag string
+	pos int
+}
+
+func (p *tagParser) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("invalid tag %q: %s at %d", p.tag, fmt.Sprintf(format, args...), pos)
+}
+
+// token reads a quoted or unquoted token until the next comma, or the next
+// "=" if isKey is true, and leaves the position on the delimiter
+func (p *tagParser) token(isKey bool) (string, error) {
+	isDelimiter := func(c byte) bool {
+		return c == ',' || (isKey && c == '=')
+	}
+
+	if p.pos < len(p.tag) && p.tag[p.pos] == '"' {
+		return p.quoted(isDelimiter)
+	}
+
+	var builder strings.Builder
+	for ; p.pos < len(p.tag) && !isDelimiter(p.tag[p.pos]); p.pos++ {
+		switch {
+		case isEscape(p.tag, p.pos):
+			p.pos++
+		case p.tag[p.pos] == '"':
+			return "", p.errorf(p.pos, "unexpected quote")
+		}
+
+		builder.WriteByte(p.tag[p.pos])
+	}
+
+	return builder.String(), nil
+}
+
+// isEscape checks whether the character at given position is a backslash
+// escaping a quote or another backslash
+func isEscape(s string, pos int) bool {
+	return s[pos] == '\\' && pos+1 < len(s) && (s[pos+1] == '"' || s[pos+1] == '\\')
+}
+
+// quoted reads a double quoted token starting at current position
+func (p *tagParser) quoted(isDelimiter func(c byte) bool) (string, error) {
+	start := p.pos
+
+	var builder strings.Builder
+	for end := start + 1; end < len(p.tag); end++ {
+		if isEscape(p.tag, end) {
+			end++
+			builder.WriteByte(p.tag[end])
+			continue
+		}
+
+		if p.tag[end] != '"' {
+			builder.WriteByte(p.tag[end])
+			continue
+		}
+
+		p.pos = end + 1
+		if p.pos < len(p.tag) && !isDelimiter(p.tag[p.pos]) {
+			return "", p.errorf(p.pos, "unexpected character after quoted value")
+		}
+
+		return builder.String(), nil
+	}
+
+	return "", p.errorf(start, "unterminated quoted value")
+}
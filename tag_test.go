@@ -0,0 +1,160 @@
+package nautilus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected Tag
+	}{
+		{"", Tag{}},
+		{"name", Tag{Name: "name"}},
+		{",omitempty", Tag{Options: []TagOption{{Key: "omitempty"}}}},
+		{"name,omitempty,min=3", Tag{Name: "name", Options: []TagOption{
+			{Key: "omitempty"},
+			{Key: "min", Value: "3", HasValue: true},
+		}}},
+		{"name,default=", Tag{Name: "name", Options: []TagOption{{Key: "default", HasValue: true}}}},
+		{`name,pattern="a,b\"c",max=5`, Tag{Name: "name", Options: []TagOption{
+			{Key: "pattern", Value: `a,b"c`, HasValue: true},
+			{Key: "max", Value: "5", HasValue: true},
+		}}},
+		{`"quoted,name",sep=","`, Tag{Name: "quoted,name", Options: []TagOption{{Key: "sep", Value: ",", HasValue: true}}}},
+		{`a=b,eq=x=y`, Tag{Name: "a=b", Options: []TagOption{{Key: "eq", Value: "x=y", HasValue: true}}}},
+		{`name,pattern=^\d+\.\w$`, Tag{Name: "name", Options: []TagOption{{Key: "pattern", Value: `^\d+\.\w$`, HasValue: true}}}},
+		{`name,pattern="\d{1,3}\s\\"`, Tag{Name: "name", Options: []TagOption{{Key: "pattern", Value: `\d{1,3}\s\`, HasValue: true}}}},
+		{`name,sep=a\\b\"c,end=\`, Tag{Name: "name", Options: []TagOption{
+			{Key: "sep", Value: `a\b"c`, HasValue: true},
+			{Key: "end", Value: `\`, HasValue: true},
+		}}},
+		{`name,"key=1"=v`, Tag{Name: "name", Options: []TagOption{{Key: "key=1", Value: "v", HasValue: true}}}},
+	}
+
+	for _, test := range tests {
+		tag, err := ParseTag(test.tag)
+
+		assert.Nil(t, err, test.tag)
+		assert.Equal(t, test.expected, tag, test.tag)
+	}
+}
+
+func TestParseTag_Errors(t *testing.T) {
+	tests := map[string]string{
+		"name,,min=3":   `invalid tag "name,,min=3": empty option at 5`,
+		"name,":         `invalid tag "name,": empty option at 5`,
+		"name,=3":       `invalid tag "name,=3": empty option at 5`,
+		`name,msg="abc`: `invalid tag "name,msg=\"abc": unterminated quoted value at 9`,
+		`name,msg="a"b`: `invalid tag "name,msg=\"a\"b": unexpected character after quoted value at 12`,
+		`name,msg=a"b"`: `invalid tag "name,msg=a\"b\"": unexpected quote at 10`,
+	}
+
+	for tag, expected := range tests {
+		_, err := ParseTag(tag)
+		assert.EqualError(t, err, expected, tag)
+	}
+}
+
+func TestTag_Option(t *testing.T) {
+	tag, err := ParseTag("name,omitempty,min=3,min=4")
+	assert.Nil(t, err)
+
+	assert.True(t, tag.HasOption("omitempty"))
+	assert.True(t, tag.HasOption("min"))
+	assert.False(t, tag.HasOption("max"))
+
+	value, ok := tag.Option("min")
+	assert.True(t, ok)
+	assert.Equal(t, "3", value)
+
+	value, ok = tag.Option("omitempty")
+	assert.True(t, ok)
+	assert.Equal(t, "", value)
+
+	_, ok = tag.Option("max")
+	assert.False(t, ok)
+}
+
+type taggedModel struct {
+	BaseTaggable
+	Name  string `validate:"required,min=3"`
+	Email string `validate:"email,msg=\"invalid, email\""`
+	Age   int
+}
+
+type TaggedBase struct {
+	ID   string `validate:"required"`
+	Name string `validate:"min=1"`
+}
+
+type embeddedTaggedModel struct {
+	BaseTaggable
+	*TaggedBase
+	Name string `validate:"min=3"`
+}
+
+// legacyTaggable implements Taggable without GetTags
+type legacyTaggable struct{}
+
+func (legacyTaggable) GetTag(caller interface{}, field string, tag string) string {
+	return ""
+}
+
+var _ Taggable = legacyTaggable{}
+
+type malformedTaggedModel struct {
+	BaseTaggable
+	Name string `validate:"required,,min=3"`
+}
+
+func TestBaseTaggable_GetTags(t *testing.T) {
+	model := &taggedModel{}
+
+	tags, err := model.GetTags(model, "validate")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]Tag{
+		"Name":  {Name: "required", Options: []TagOption{{Key: "min", Value: "3", HasValue: true}}},
+		"Email": {Name: "email", Options: []TagOption{{Key: "msg", Value: "invalid, email", HasValue: true}}},
+	}, tags)
+
+	// Returned tags are copies of the cached ones
+	tags["Name"].Options[0].Value = "10"
+	delete(tags, "Email")
+
+	tags, err = model.GetTags(*model, "validate")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", tags["Name"].Options[0].Value)
+	assert.Len(t, tags, 2)
+
+	tags, err = model.GetTags(model, "json")
+	assert.Nil(t, err)
+	assert.Empty(t, tags)
+
+	_, err = model.GetTags(42, "validate")
+	assert.EqualError(t, err, "argument is not a struct")
+
+	malformed := malformedTaggedModel{}
+	_, err = malformed.GetTags(malformed, "validate")
+	assert.EqualError(t, err, `field Name: invalid tag "required,,min=3": empty option at 9`)
+}
+
+func TestBaseTaggable_GetTagsPromoted(t *testing.T) {
+	model := embeddedTaggedModel{}
+
+	tags, err := model.GetTags(model, "validate")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]Tag{
+		"ID":   {Name: "required"},
+		"Name": {Name: "min=3"},
+	}, tags)
+
+	assert.Equal(t, "required", model.GetTag(model, "ID", "validate"))
+
+	_, ok := interface{}(model).(TagsGetter)
+	assert.True(t, ok)
+	_, ok = interface{}(legacyTaggable{}).(TagsGetter)
+	assert.False(t, ok)
+}
@@ -0,0 +1,214 @@
+package nautilus
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// packagePathPattern matches package paths of qualified type names, such
+// as "github.com/acme/models." in "github.com/acme/models.User"
+var packagePathPattern = regexp.MustCompile(`([\w\-.~]+/)*[\w\-~]+\.`)
+
+// TypeName contains different names of a type
+type TypeName struct {
+	// Name is the short name of the type without package paths, e.g.
+	// "User", "[]*User", "map[string]User" or "Page[User]" for generic types
+	Name string
+
+	// QualifiedName is the name of the type with package paths, e.g.
+	// "github.com/acme/models.User", which is unique among all types except
+	// for the types with the same name declared inside different functions
+	// of a package. Unexported fields and methods of unnamed structs and
+	// interfaces are qualified too, e.g. "struct { github.com/acme/models.id int }".
+	QualifiedName string
+
+	// Description is a readable description of the type, e.g. "slice of
+	// pointer to User" or "map of string to User"
+	Description string
+}
+
+// GetTypeName returns names of the type of given value. It returns zero
+// TypeName for nil.
+func GetTypeName(v interface{}) TypeName {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return TypeName{}
+	}
+
+	return TypeNameOf(t)
+}
+
+// TypeNameOf returns names of given type
+func TypeNameOf(t reflect.Type) TypeName {
+	return TypeName{
+		Name:          formatType(t, false),
+		QualifiedName: formatType(t, true),
+		Description:   describeType(t),
+	}
+}
+
+// formatType formats given type in Go syntax, with or without package paths
+func formatType(t reflect.Type, qualified bool) string {
+	if name := t.Name(); name != "" {
+		if !qualified {
+			return shortTypeName(name)
+		}
+
+		if t.PkgPath() == "" {
+			return name
+		}
+
+		return t.PkgPath() + "." + name
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + formatType(t.Elem(), qualified)
+	case reflect.Slice:
+		return "[]" + formatType(t.Elem(), qualified)
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), formatType(t.Elem(), qualified))
+	case reflect.Map:
+		return "map[" + formatType(t.Key(), qualified) + "]" + formatType(t.Elem(), qualified)
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			return "<-chan " + formatType(t.Elem(), qualified)
+		case reflect.SendDir:
+			return "chan<- " + formatType(t.Elem(), qualified)
+		default:
+			return "chan " + formatType(t.Elem(), qualified)
+		}
+	case reflect.Func:
+		return "func" + formatFuncSignature(t, qualified)
+	case reflect.Struct:
+		return formatStruct(t, qualified)
+	case reflect.Interface:
+		return formatInterface(t, qualified)
+	}
+
+	if qualified {
+		return t.String()
+	}
+
+	return shortTypeName(t.String())
+}
+
+// formatFuncSignature formats parameters and results of given func type
+func formatFuncSignature(t reflect.Type, qualified bool) string {
+	in := make([]string, t.NumIn())
+	for i := range in {
+		if t.IsVariadic() && i == len(in)-1 {
+			in[i] = "..." + formatType(t.In(i).Elem(), qualified)
+		} else {
+			in[i] = formatType(t.In(i), qualified)
+		}
+	}
+
+	out := make([]string, t.NumOut())
+	for i := range out {
+		out[i] = formatType(t.Out(i), qualified)
+	}
+
+	signature := "(" + strings.Join(in, ", ") + ")"
+
+	switch len(out) {
+	case 0:
+		return signature
+	case 1:
+		return signature + " " + out[0]
+	default:
+		return signature + " (" + strings.Join(out, ", ") + ")"
+	}
+}
+
+// formatStruct formats fields of given unnamed struct type. Unexported
+// fields are qualified by their package path in qualified names, since
+// structs with unexported fields of different packages are different types.
+func formatStruct(t reflect.Type, qualified bool) string {
+	if t.NumField() == 0 {
+		return "struct {}"
+	}
+
+	fields := make([]string, t.NumField())
+	for i := range fields {
+		field := t.Field(i)
+
+		fields[i] = formatType(field.Type, qualified)
+		if !field.Anonymous {
+			fields[i] = qualifiedMemberName(field.Name, field.PkgPath, qualified) + " " + fields[i]
+		}
+
+		if field.Tag != "" {
+			fields[i] += " " + strconv.Quote(string(field.Tag))
+		}
+	}
+
+	return "struct { " + strings.Join(fields, "; ") + " }"
+}
+
+// formatInterface formats methods of given unnamed interface type
+func formatInterface(t reflect.Type, qualified bool) string {
+	if t.NumMethod() == 0 {
+		return "interface{}"
+	}
+
+	methods := make([]string, t.NumMethod())
+	for i := range methods {
+		method := t.Method(i)
+		methods[i] = qualifiedMemberName(method.Name, method.PkgPath, qualified) + formatFuncSignature(method.Type, qualified)
+	}
+
+	return "interface { " + strings.Join(methods, "; ") + " }"
+}
+
+// qualifiedMemberName returns the name of a field or method, prefixed by
+// its package path if it is unexported and the name is qualified
+func qualifiedMemberName(name string, pkgPath string, qualified bool) string {
+	if !qualified || pkgPath == "" {
+		return name
+	}
+
+	return pkgPath + "." + name
+}
+
+// shortTypeName removes package paths of given type name, including the
+// ones in type parameters of generic types, e.g. "Page[models.User]" is
+// converted to "Page[User]"
+func shortTypeName(name string) string {
+	return packagePathPattern.ReplaceAllString(name, "")
+}
+
+// describeType returns a readable description of given type
+func describeType(t reflect.Type) string {
+	if t.Name() != "" {
+		return shortTypeName(t.Name())
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "pointer to " + describeType(t.Elem())
+	case reflect.Slice:
+		return "slice of " + describeType(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("array of %d %s", t.Len(), describeType(t.Elem()))
+	case reflect.Map:
+		return "map of " + describeType(t.Key()) + " to " + describeType(t.Elem())
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			return "receive-only channel of " + describeType(t.Elem())
+		case reflect.SendDir:
+			return "send-only channel of " + describeType(t.Elem())
+		default:
+			return "channel of " + describeType(t.Elem())
+		}
+	case reflect.Func:
+		return "function" + formatFuncSignature(t, false)
+	default:
+		return formatType(t, false)
+	}
+}
@@ -0,0 +1,72 @@
+package nautilus
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typeNamePage[T any] struct {
+	Items []T
+}
+
+func TestGetTypeName(t *testing.T) {
+	tests := []struct {
+		value       interface{}
+		name        string
+		qualified   string
+		description string
+	}{
+		{1, "int", "int", "int"},
+		{sampleStruct{}, "sampleStruct", "github.com/Kamva/nautilus.sampleStruct", "sampleStruct"},
+		{&sampleStruct{}, "*sampleStruct", "*github.com/Kamva/nautilus.sampleStruct", "pointer to sampleStruct"},
+		{[]*time.Time{}, "[]*Time", "[]*time.Time", "slice of pointer to Time"},
+		{[2]string{}, "[2]string", "[2]string", "array of 2 string"},
+		{map[string]sampleStruct{}, "map[string]sampleStruct", "map[string]github.com/Kamva/nautilus.sampleStruct", "map of string to sampleStruct"},
+		{make(chan int), "chan int", "chan int", "channel of int"},
+		{make(<-chan error), "<-chan error", "<-chan error", "receive-only channel of error"},
+		{func(int, ...string) error { return nil }, "func(int, ...string) error", "func(int, ...string) error", "function(int, ...string) error"},
+		{func() (int, error) { return 0, nil }, "func() (int, error)", "func() (int, error)", "function() (int, error)"},
+		{[]interface{}{}, "[]interface{}", "[]interface{}", "slice of interface{}"},
+		{
+			struct {
+				ID   int `json:"id"`
+				Time time.Time
+				time.Duration
+				name string
+			}{},
+			`struct { ID int "json:\"id\""; Time Time; Duration; name string }`,
+			`struct { ID int "json:\"id\""; Time time.Time; time.Duration; github.com/Kamva/nautilus.name string }`,
+			`struct { ID int "json:\"id\""; Time Time; Duration; name string }`,
+		},
+		{
+			(*interface {
+				Stringer() fmt.Stringer
+				value() time.Time
+			})(nil),
+			"*interface { Stringer() Stringer; value() Time }",
+			"*interface { Stringer() fmt.Stringer; github.com/Kamva/nautilus.value() time.Time }",
+			"pointer to interface { Stringer() Stringer; value() Time }",
+		},
+		{
+			typeNamePage[time.Time]{},
+			"typeNamePage[Time]",
+			"github.com/Kamva/nautilus.typeNamePage[time.Time]",
+			"typeNamePage[Time]",
+		},
+	}
+
+	for _, test := range tests {
+		name := GetTypeName(test.value)
+
+		assert.Equal(t, test.name, name.Name)
+		assert.Equal(t, test.qualified, name.QualifiedName)
+		assert.Equal(t, test.description, name.Description)
+	}
+
+	assert.Equal(t, TypeName{}, GetTypeName(nil))
+	assert.Equal(t, "error", TypeNameOf(reflect.TypeOf((*error)(nil)).Elem()).Name)
+}
@@ -1,4 +1,93 @@
 package types
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // JSONMap is a map represent a json in key value format
 type JSONMap map[string]interface{}
+
+// Get returns the value in given path of nested maps and slices, such as
+// "user.name" or "user.addresses[0].city". Slice indices could be written
+// after a dot too, e.g. "addresses.0.city". It reports false if any part of
+// the path does not exist.
+func (m JSONMap) Get(path string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(m)
+
+	for _, key := range splitPath(path) {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if current, ok = value[key]; !ok {
+				return nil, false
+			}
+		case JSONMap:
+			var ok bool
+			if current, ok = value[key]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, false
+			}
+
+			current = value[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// splitPath splits given path into keys and indices
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	})
+}
+
+// Value implements driver.Valuer interface, so JSONMap could be stored
+// in json or jsonb columns. A nil map is stored as NULL.
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(map[string]interface{}(m))
+}
+
+// Scan implements sql.Scanner interface, so JSONMap could be loaded from
+// json or jsonb columns. A NULL value results in a nil map.
+func (m *JSONMap) Scan(src interface{}) error {
+	if m == nil {
+		return errors.New("types: Scan on nil pointer")
+	}
+
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("types: cannot scan type %T into JSONMap", src)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	*m = result
+	return nil
+}
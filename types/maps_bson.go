@@ -0,0 +1,99 @@
+//go:build bson
+// +build bson
+
+package types
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MarshalBSON implements bson.Marshaler interface, so JSONMap could be
+// marshaled as a document. A nil map is marshaled as an empty document,
+// as a document could not be null.
+func (m JSONMap) MarshalBSON() ([]byte, error) {
+	if m == nil {
+		return bson.Marshal(bson.M{})
+	}
+
+	return bson.Marshal(map[string]interface{}(m))
+}
+
+// MarshalBSONValue implements bson.ValueMarshaler interface, so JSONMap
+// fields are stored as embedded documents in MongoDB. Similar to Value, a
+// nil map is stored as null.
+func (m JSONMap) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if m == nil {
+		return bsontype.Null, nil, nil
+	}
+
+	data, err := bson.Marshal(map[string]interface{}(m))
+	return bsontype.EmbeddedDocument, data, err
+}
+
+// UnmarshalBSON implements bson.Unmarshaler interface. Embedded documents
+// and arrays are converted to their json compatible types, so the result
+// is identical to a JSONMap decoded from json.
+func (m *JSONMap) UnmarshalBSON(data []byte) error {
+	var result map[string]interface{}
+	if err := bson.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	*m = normalizeBSONMap(result)
+	return nil
+}
+
+// UnmarshalBSONValue implements bson.ValueUnmarshaler interface. Similar
+// to Scan, a null value results in a nil map.
+func (m *JSONMap) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Null, bsontype.Undefined:
+		*m = nil
+		return nil
+	case bsontype.EmbeddedDocument:
+		return m.UnmarshalBSON(data)
+	default:
+		return fmt.Errorf("types: cannot unmarshal bson %s into JSONMap", t)
+	}
+}
+
+// normalizeBSONMap converts bson primitives of given map in place
+func normalizeBSONMap(m map[string]interface{}) map[string]interface{} {
+	for key, value := range m {
+		m[key] = normalizeBSONValue(value)
+	}
+
+	return m
+}
+
+// normalizeBSONValue converts bson documents and arrays into
+// map[string]interface{} and []interface{}
+func normalizeBSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.D:
+		return normalizeBSONMap(v.Map())
+	case primitive.M:
+		return normalizeBSONMap(v)
+	case map[string]interface{}:
+		return normalizeBSONMap(v)
+	case primitive.A:
+		return normalizeBSONSlice(v)
+	case []interface{}:
+		return normalizeBSONSlice(v)
+	default:
+		return v
+	}
+}
+
+// normalizeBSONSlice converts bson primitives of given slice in place
+func normalizeBSONSlice(s []interface{}) []interface{} {
+	for i, value := range s {
+		s[i] = normalizeBSONValue(value)
+	}
+
+	return s
+}
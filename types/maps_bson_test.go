@@ -0,0 +1,59 @@
+//go:build bson
+// +build bson
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+func TestJSONMap_BSON(t *testing.T) {
+	type document struct {
+		Data JSONMap `bson:"data"`
+	}
+
+	original := document{Data: JSONMap{
+		"name":  "nautilus",
+		"tags":  []interface{}{"a", "b"},
+		"inner": map[string]interface{}{"ok": true},
+	}}
+
+	data, err := bson.Marshal(original)
+	assert.Nil(t, err)
+
+	var result document
+	err = bson.Unmarshal(data, &result)
+
+	assert.Nil(t, err)
+	assert.Equal(t, original, result)
+}
+
+func TestJSONMap_BSONNil(t *testing.T) {
+	type document struct {
+		Data JSONMap `bson:"data"`
+	}
+
+	data, err := bson.Marshal(document{})
+	assert.Nil(t, err)
+
+	raw, err := bson.Raw(data).LookupErr("data")
+	assert.Nil(t, err)
+	assert.Equal(t, bsontype.Null, raw.Type)
+
+	result := document{Data: JSONMap{"stale": true}}
+	assert.Nil(t, bson.Unmarshal(data, &result))
+	assert.Nil(t, result.Data)
+
+	// Same as the database/sql implementation
+	value, err := JSONMap(nil).Value()
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	var scanned = JSONMap{"stale": true}
+	assert.Nil(t, scanned.Scan(nil))
+	assert.Nil(t, scanned)
+}
@@ -0,0 +1,229 @@
+package types
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDriver is an in-memory sql driver that stores every inserted
+// argument and returns them back on query
+type stubDriver struct {
+	mu   sync.Mutex
+	rows []driver.Value
+}
+
+func (d *stubDriver) Open(string) (driver.Conn, error) {
+	return &stubConn{driver: d}, nil
+}
+
+type stubConn struct {
+	driver *stubDriver
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{conn: c, query: query}, nil
+}
+
+func (c *stubConn) Close() error {
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type stubStmt struct {
+	conn  *stubConn
+	query string
+}
+
+func (s *stubStmt) Close() error {
+	return nil
+}
+
+func (s *stubStmt) NumInput() int {
+	if s.query == "INSERT" {
+		return 1
+	}
+
+	return 0
+}
+
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+
+	s.conn.driver.rows = append(s.conn.driver.rows, args[0])
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stubStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+
+	rows := make([]driver.Value, len(s.conn.driver.rows))
+	copy(rows, s.conn.driver.rows)
+
+	return &stubRows{rows: rows}, nil
+}
+
+type stubRows struct {
+	rows []driver.Value
+	pos  int
+}
+
+func (r *stubRows) Columns() []string {
+	return []string{"data"}
+}
+
+func (r *stubRows) Close() error {
+	return nil
+}
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+
+	dest[0] = r.rows[r.pos]
+	r.pos++
+
+	return nil
+}
+
+var stub = &stubDriver{}
+
+func init() {
+	sql.Register("jsonmap_stub", stub)
+}
+
+func TestJSONMap_SQL(t *testing.T) {
+	db, err := sql.Open("jsonmap_stub", "")
+	assert.Nil(t, err)
+	defer db.Close()
+
+	stub.rows = nil
+
+	original := JSONMap{
+		"name":  "nautilus",
+		"count": float64(3),
+		"tags":  []interface{}{"a", "b"},
+		"inner": map[string]interface{}{"ok": true},
+	}
+
+	_, err = db.Exec("INSERT", original)
+	assert.Nil(t, err)
+	_, err = db.Exec("INSERT", JSONMap(nil))
+	assert.Nil(t, err)
+
+	rows, err := db.Query("SELECT")
+	assert.Nil(t, err)
+	defer rows.Close()
+
+	var result []JSONMap
+	for rows.Next() {
+		var m JSONMap
+		assert.Nil(t, rows.Scan(&m))
+		result = append(result, m)
+	}
+
+	assert.Nil(t, rows.Err())
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, original, result[0])
+	assert.Nil(t, result[1])
+}
+
+func TestJSONMap_Value(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		value, err := JSONMap(nil).Value()
+
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+	})
+	t.Run("map", func(t *testing.T) {
+		value, err := JSONMap{"key": "value"}.Value()
+
+		assert.Nil(t, err)
+		assert.Equal(t, []byte(`{"key":"value"}`), value)
+	})
+	t.Run("error", func(t *testing.T) {
+		_, err := JSONMap{"key": make(chan int)}.Value()
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestJSONMap_Scan(t *testing.T) {
+	t.Run("bytes", func(t *testing.T) {
+		var m JSONMap
+		err := m.Scan([]byte(`{"key":"value"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, JSONMap{"key": "value"}, m)
+	})
+	t.Run("string", func(t *testing.T) {
+		var m JSONMap
+		err := m.Scan(`{"key":1}`)
+
+		assert.Nil(t, err)
+		assert.Equal(t, JSONMap{"key": float64(1)}, m)
+	})
+	t.Run("null", func(t *testing.T) {
+		m := JSONMap{"key": "value"}
+		err := m.Scan(nil)
+
+		assert.Nil(t, err)
+		assert.Nil(t, m)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		var m JSONMap
+		err := m.Scan(42)
+
+		assert.NotNil(t, err)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		var m JSONMap
+		err := m.Scan(`[1, 2]`)
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestJSONMap_Get(t *testing.T) {
+	m := JSONMap{
+		"name": "john",
+		"user": map[string]interface{}{
+			"addresses": []interface{}{
+				map[string]interface{}{"city": "Tehran"},
+				JSONMap{"city": "Shiraz"},
+			},
+		},
+		"nil": nil,
+	}
+
+	paths := map[string]interface{}{
+		"name":                   "john",
+		"user.addresses[0].city": "Tehran",
+		"user.addresses.1.city":  "Shiraz",
+		"user.addresses[1]":      JSONMap{"city": "Shiraz"},
+		"nil":                    nil,
+	}
+
+	for path, expected := range paths {
+		value, ok := m.Get(path)
+		assert.True(t, ok, path)
+		assert.Equal(t, expected, value, path)
+	}
+
+	for _, path := range []string{"missing", "name.first", "user.addresses[2]", "user.addresses[-1]", "user.addresses.x", "nil.x"} {
+		_, ok := m.Get(path)
+		assert.False(t, ok, path)
+	}
+}
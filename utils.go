@@ -1,35 +1,194 @@
 package nautilus
 
 import (
-	"encoding/json"
-	"os"
 	"reflect"
 )
 
+// zeroer is implemented by types that define their own zero value check,
+// such as time.Time
+type zeroer interface {
+	IsZero() bool
+}
+
+var zeroerType = reflect.TypeOf((*zeroer)(nil)).Elem()
+
 // GetEnv get the environment variable and set it to given fallback if not present
 func GetEnv(key string, fallback string) string {
-	value := os.Getenv(key)
+	return GetEnvFrom(EnvSource(), key, fallback)
+}
+
+// Empty Check if i is empty. It is an alias for IsEmpty.
+func Empty(i interface{}) bool {
+	return IsEmpty(i)
+}
+
+// IsZero checks if i is the zero value of its type. Values implementing
+// `IsZero() bool` method (e.g. time.Time) are checked by that method.
+// Pointers are zero only if they are nil, regardless of the pointed value.
+func IsZero(i interface{}) bool {
+	return isZeroValue(reflect.ValueOf(i))
+}
+
+// IsEmpty checks if i has no content. Nil values, zero values (as defined
+// by IsZero) and empty strings, slices, maps and arrays are empty. Pointers
+// are empty if they are nil or point to an empty value. Unlike IsZero, a
+// non-nil slice or map with no element is empty too.
+func IsEmpty(i interface{}) bool {
+	return isEmptyValue(reflect.ValueOf(i), false)
+}
+
+// IsDeepEmpty is like IsEmpty but also checks the content of values. Slices,
+// arrays and maps are deeply empty if all of their elements are deeply
+// empty and structs are deeply empty if all of their fields are deeply
+// empty. For example []int{0} is deeply empty but not empty. References
+// reached again through a cycle add no content, for both IsEmpty and
+// IsDeepEmpty.
+func IsDeepEmpty(i interface{}) bool {
+	return isEmptyValue(reflect.ValueOf(i), true)
+}
+
+func isZeroValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
 
-	if len(value) == 0 {
-		return fallback
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+		if z, ok := asZeroer(v); ok {
+			return z.IsZero()
+		}
 	}
 
-	return value
+	return v.IsZero()
 }
 
-// Empty Check if i is empty (Zero valued)
-func Empty(i interface{}) bool {
-	if i == nil {
+// asZeroer returns given value as zeroer if its type or its pointer type
+// implements the interface
+func asZeroer(v reflect.Value) (zeroer, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+
+	if v.Type().Implements(zeroerType) {
+		return v.Interface().(zeroer), true
+	}
+
+	if reflect.PtrTo(v.Type()).Implements(zeroerType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+
+		return ptr.Interface().(zeroer), true
+	}
+
+	return nil, false
+}
+
+// visitKey identifies a reference visited by an emptiness check. The
+// type is part of the key, since a struct and its first field have the
+// same address.
+type visitKey struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+func isEmptyValue(v reflect.Value, deep bool) bool {
+	c := emptinessChecker{deep: deep, visited: make(map[visitKey]bool)}
+
+	return c.empty(v)
+}
+
+// emptinessChecker checks emptiness of values, keeping track of visited
+// references to stop on cycles. A reference which is visited again adds no
+// content, since the content of its first visit is being checked already.
+type emptinessChecker struct {
+	deep    bool
+	visited map[visitKey]bool
+}
+
+// visit marks the reference of given pointer, map or slice as visited and
+// reports whether it is visited for the first time
+func (c emptinessChecker) visit(v reflect.Value) bool {
+	key := visitKey{typ: v.Type(), ptr: v.Pointer()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+
+	if c.visited[key] {
+		return false
+	}
+
+	c.visited[key] = true
+
+	return true
+}
+
+func (c emptinessChecker) empty(v reflect.Value) bool {
+	if !v.IsValid() {
 		return true
 	}
 
-	v := reflect.ValueOf(i)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return true
+		}
+
+		if v.Kind() == reflect.Ptr && !c.visit(v) {
+			return true
+		}
+
+		return c.empty(v.Elem())
+	case reflect.String:
+		return v.Len() == 0
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return true
+		}
+	}
+
+	if !c.deep {
+		return isZeroValue(v)
+	}
+
+	if z, ok := asZeroer(v); ok {
+		return z.IsZero()
+	}
 
 	switch v.Kind() {
-	case reflect.Map, reflect.Array, reflect.Slice, reflect.Struct:
-		data, _ := json.Marshal(i)
-		return string(data) == "[]" || string(data) == "{}"
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && !c.visit(v) {
+			return true
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if !c.empty(v.Index(i)) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Map:
+		if !c.visit(v) {
+			return true
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			if !c.empty(iter.Value()) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !c.empty(v.Field(i)) {
+				return false
+			}
+		}
+
+		return true
 	default:
-		return reflect.DeepEqual(i, reflect.Zero(reflect.TypeOf(i)).Interface())
+		return v.IsZero()
 	}
 }
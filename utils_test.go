@@ -0,0 +1,181 @@
+package nautilus
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type unexportedOnly struct {
+	value int
+}
+
+type customZero struct {
+	Value int
+}
+
+func (c customZero) IsZero() bool {
+	return c.Value <= 0
+}
+
+type pointerZero struct {
+	Value string
+}
+
+func (p *pointerZero) IsZero() bool {
+	return p.Value == "zero"
+}
+
+type emptinessCase struct {
+	name      string
+	value     interface{}
+	zero      bool
+	empty     bool
+	deepEmpty bool
+}
+
+var emptyString = ""
+var nonEmptyString = "value"
+
+var emptinessCases = []emptinessCase{
+	{"nil", nil, true, true, true},
+	{"zero int", 0, true, true, true},
+	{"int", 1, false, false, false},
+	{"empty string", "", true, true, true},
+	{"string", "str", false, false, false},
+	{"false", false, true, true, true},
+	{"nil pointer", (*int)(nil), true, true, true},
+	{"pointer to empty", &emptyString, false, true, true},
+	{"pointer to value", &nonEmptyString, false, false, false},
+	{"nil slice", []int(nil), true, true, true},
+	{"empty slice", []int{}, false, true, true},
+	{"slice of zero", []int{0}, false, false, true},
+	{"slice", []int{1}, false, false, false},
+	{"empty map", map[string]int{}, false, true, true},
+	{"map of zero", map[string]int{"a": 0}, false, false, true},
+	{"zero array", [2]int{}, true, true, true},
+	{"array", [2]int{0, 1}, false, false, false},
+	{"zero struct", sampleStruct{}, true, true, true},
+	{"struct", sampleStruct{Exposed: 1}, false, false, false},
+	{"unexported field", unexportedOnly{value: 1}, false, false, false},
+	{"struct with empty slice", struct{ S []int }{S: []int{}}, false, false, true},
+	{"zero time", time.Time{}, true, true, true},
+	{"time", time.Now(), false, false, false},
+	{"custom zero", customZero{Value: -1}, true, true, true},
+	{"custom non-zero", customZero{Value: 1}, false, false, false},
+	{"pointer receiver zero", pointerZero{Value: "zero"}, true, true, true},
+	{"pointer receiver non-zero", pointerZero{}, false, false, false},
+	{"nil chan", (chan int)(nil), true, true, true},
+	{"chan", make(chan int), false, false, false},
+}
+
+func TestIsZero(t *testing.T) {
+	for _, c := range emptinessCases {
+		assert.Equal(t, c.zero, IsZero(c.value), c.name)
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	for _, c := range emptinessCases {
+		assert.Equal(t, c.empty, IsEmpty(c.value), c.name)
+		assert.Equal(t, c.empty, Empty(c.value), c.name)
+	}
+}
+
+func TestIsDeepEmpty(t *testing.T) {
+	for _, c := range emptinessCases {
+		assert.Equal(t, c.deepEmpty, IsDeepEmpty(c.value), c.name)
+	}
+}
+
+type cyclicNode struct {
+	Value int
+	Next  *cyclicNode
+}
+
+type selfPointer *selfPointer
+
+func TestIsEmpty_Cycles(t *testing.T) {
+	node := &cyclicNode{}
+	node.Next = node
+	assert.False(t, IsEmpty(node))
+	assert.True(t, IsDeepEmpty(node))
+
+	node.Next = &cyclicNode{Value: 1, Next: node}
+	assert.False(t, IsDeepEmpty(node))
+
+	var pointer selfPointer
+	pointer = &pointer
+	assert.True(t, IsEmpty(pointer))
+	assert.True(t, IsDeepEmpty(pointer))
+
+	var iface interface{}
+	iface = &iface
+	assert.True(t, IsEmpty(iface))
+	assert.True(t, IsDeepEmpty(iface))
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	assert.False(t, IsEmpty(m))
+	assert.True(t, IsDeepEmpty(m))
+
+	m["value"] = 1
+	assert.False(t, IsDeepEmpty(m))
+}
+
+// jsonEmpty is the previous json based implementation of Empty, kept for
+// comparing benchmarks
+func jsonEmpty(i interface{}) bool {
+	if i == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(i)
+
+	switch v.Kind() {
+	case reflect.Map, reflect.Array, reflect.Slice, reflect.Struct:
+		data, _ := json.Marshal(i)
+		return string(data) == "[]" || string(data) == "{}"
+	default:
+		return reflect.DeepEqual(i, reflect.Zero(reflect.TypeOf(i)).Interface())
+	}
+}
+
+var benchmarkValues = []interface{}{
+	0, "str", []int{1, 2, 3}, map[string]int{"a": 1}, sampleStruct{Exposed: 1}, time.Now(),
+}
+
+func BenchmarkJSONEmpty(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, v := range benchmarkValues {
+			jsonEmpty(v)
+		}
+	}
+}
+
+func BenchmarkIsZero(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, v := range benchmarkValues {
+			IsZero(v)
+		}
+	}
+}
+
+func BenchmarkIsEmpty(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, v := range benchmarkValues {
+			IsEmpty(v)
+		}
+	}
+}
+
+func BenchmarkIsDeepEmpty(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, v := range benchmarkValues {
+			IsDeepEmpty(v)
+		}
+	}
+}
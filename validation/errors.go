@@ -0,0 +1,58 @@
+package validation
+
+import (
+	"sort"
+	"strings"
+)
+
+// FieldError is a failed validation rule of a field
+type FieldError struct {
+	// Rule is the name of failed rule, such as "required" or "min"
+	Rule string `json:"rule"`
+
+	// Param is the parameter of the rule, e.g. "3" in "min=3"
+	Param string `json:"param,omitempty"`
+
+	// Message is a human readable description of the error
+	Message string `json:"message"`
+}
+
+// Errors contains failed rules of invalid fields, keyed by the JSON path of
+// fields such as "email", "address.city" or "items[0].name". It implements
+// error interface, so it could be returned as validation error.
+type Errors map[string][]FieldError
+
+// Error implements error interface
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	var messages []string
+	for _, field := range fields {
+		for _, err := range e[field] {
+			messages = append(messages, field+": "+err.Message)
+		}
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Messages returns messages of errors keyed by field path
+func (e Errors) Messages() map[string][]string {
+	messages := make(map[string][]string, len(e))
+	for field, errs := range e {
+		for _, err := range errs {
+			messages[field] = append(messages[field], err.Message)
+		}
+	}
+
+	return messages
+}
+
+func (e Errors) add(field string, err FieldError) {
+	e[field] = append(e[field], err)
+}
@@ -0,0 +1,41 @@
+package validation
+
+import (
+	"errors"
+
+	"github.com/Kamva/nautilus"
+	"github.com/kataras/iris/v12"
+)
+
+// Context values which are set by HandleError and rendered by the error
+// handlers of bootstrap application
+const (
+	MessageKey = nautilus.ErrorMessageKey
+	CodeKey    = nautilus.ErrorCodeKey
+	ErrorsKey  = nautilus.ErrorFieldsKey
+)
+
+// ErrorCode is the code of validation errors in responses
+const ErrorCode = "validation_failed"
+
+// HandleError prepares given validation error to be rendered by the error
+// handlers of the application. It sets a nautilus.AppError with 422
+// status and the validation errors as its fields by nautilus.SetError,
+// which sets "message", "code" and "errors" values of the context too and
+// stops the execution of next handlers. It returns false if err is not a validation
+// error, so it could be handled by the caller.
+//
+//	if err := validation.Validate(request); validation.HandleError(ctx, err) {
+//		return
+//	}
+func HandleError(ctx iris.Context, err error) bool {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return false
+	}
+
+	appErr := nautilus.NewAppError(iris.StatusUnprocessableEntity, ErrorCode, "validation failed")
+	nautilus.SetError(ctx, appErr.WithFields(errs).WithCause(err))
+
+	return true
+}
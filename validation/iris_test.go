@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleError(t *testing.T) {
+	type request struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	app := iris.New()
+	app.OnAnyErrorCode(func(ctx iris.Context) {
+		_, _ = ctx.JSON(iris.Map{
+			"message": ctx.Values().GetString(MessageKey),
+			"code":    ctx.Values().GetString(CodeKey),
+			"errors":  ctx.Values().Get(ErrorsKey),
+		})
+	})
+
+	app.Post("/", func(ctx iris.Context) {
+		var r request
+		_ = ctx.ReadJSON(&r)
+
+		if HandleError(ctx, Validate(r)) {
+			return
+		}
+
+		_, _ = ctx.WriteString("ok")
+	})
+
+	assert.Nil(t, app.Build())
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		app.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	response := serve(`{"name":"john"}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "ok", response.Body.String())
+
+	response = serve(`{}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	assert.JSONEq(t, `{
+		"message": "validation failed",
+		"code": "validation_failed",
+		"errors": {"name": [{"rule": "required", "message": "is required"}]}
+	}`, response.Body.String())
+
+	ctx := app.ContextPool.Acquire(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	defer app.ContextPool.Release(ctx)
+
+	assert.False(t, HandleError(ctx, errors.New("other error")))
+	assert.False(t, HandleError(ctx, nil))
+}
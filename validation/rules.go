@@ -0,0 +1,397 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	emailPattern    = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+	alphaPattern    = regexp.MustCompile(`^[a-zA-Z]+$`)
+	alphanumPattern = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	numericPattern  = regexp.MustCompile(`^[-+]?[0-9]+(\.[0-9]+)?$`)
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// builtinRules contains rules which are registered in all validators
+var builtinRules = map[string]rule{
+	"required": {fn: required, message: paramMessage("is required")},
+
+	"len": {
+		fn:      compareRule(func(cmp int) bool { return cmp == 0 }),
+		message: sizeMessage("must be {param}", "must be {param} characters long", "must contain {param} items"),
+	},
+	"min": {
+		fn:      compareRule(func(cmp int) bool { return cmp >= 0 }),
+		message: sizeMessage("must be at least {param}", "must be at least {param} characters long", "must contain at least {param} items"),
+	},
+	"max": {
+		fn:      compareRule(func(cmp int) bool { return cmp <= 0 }),
+		message: sizeMessage("must be at most {param}", "must be at most {param} characters long", "must contain at most {param} items"),
+	},
+	"gt": {
+		fn:      compareRule(func(cmp int) bool { return cmp > 0 }),
+		message: sizeMessage("must be greater than {param}", "must be longer than {param} characters", "must contain more than {param} items"),
+	},
+	"gte": {
+		fn:      compareRule(func(cmp int) bool { return cmp >= 0 }),
+		message: sizeMessage("must be greater than or equal to {param}", "must be at least {param} characters long", "must contain at least {param} items"),
+	},
+	"lt": {
+		fn:      compareRule(func(cmp int) bool { return cmp < 0 }),
+		message: sizeMessage("must be less than {param}", "must be shorter than {param} characters", "must contain less than {param} items"),
+	},
+	"lte": {
+		fn:      compareRule(func(cmp int) bool { return cmp <= 0 }),
+		message: sizeMessage("must be less than or equal to {param}", "must be at most {param} characters long", "must contain at most {param} items"),
+	},
+	"eq": {fn: equalRule(true), message: paramMessage("must be equal to {param}")},
+	"ne": {fn: equalRule(false), message: paramMessage("must not be equal to {param}")},
+
+	"oneof": {fn: oneOf, message: func(field Field) string {
+		return "must be one of " + strings.Join(strings.Fields(field.Param), ", ")
+	}},
+
+	"email":    {fn: patternRule(emailPattern), message: paramMessage("must be a valid email address")},
+	"url":      {fn: validURL, message: paramMessage("must be a valid URL")},
+	"alpha":    {fn: patternRule(alphaPattern), message: paramMessage("must contain only letters")},
+	"alphanum": {fn: patternRule(alphanumPattern), message: paramMessage("must contain only letters and numbers")},
+	"numeric":  {fn: patternRule(numericPattern), message: paramMessage("must be a number")},
+	"uuid":     {fn: patternRule(uuidPattern), message: paramMessage("must be a valid UUID")},
+
+	"eqfield":  {fn: fieldRule(func(cmp int) bool { return cmp == 0 }), message: paramMessage("must be equal to {param}"), crossField: true},
+	"nefield":  {fn: fieldRule(func(cmp int) bool { return cmp != 0 }), message: paramMessage("must not be equal to {param}"), crossField: true},
+	"gtfield":  {fn: fieldRule(func(cmp int) bool { return cmp > 0 }), message: paramMessage("must be greater than {param}"), crossField: true},
+	"gtefield": {fn: fieldRule(func(cmp int) bool { return cmp >= 0 }), message: paramMessage("must be greater than or equal to {param}"), crossField: true},
+	"ltfield":  {fn: fieldRule(func(cmp int) bool { return cmp < 0 }), message: paramMessage("must be less than {param}"), crossField: true},
+	"ltefield": {fn: fieldRule(func(cmp int) bool { return cmp <= 0 }), message: paramMessage("must be less than or equal to {param}"), crossField: true},
+}
+
+// paramMessage returns a message generator which replaces "{param}" in
+// given message with the rule parameter
+func paramMessage(message string) func(field Field) string {
+	return func(field Field) string {
+		return strings.Replace(message, "{param}", field.Param, -1)
+	}
+}
+
+// sizeMessage returns a message generator which uses the message of the
+// field kind, since sizes of strings and collections are their lengths
+func sizeMessage(number string, length string, items string) func(field Field) string {
+	return func(field Field) string {
+		switch field.Value.Kind() {
+		case reflect.String:
+			return paramMessage(length)(field)
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return paramMessage(items)(field)
+		default:
+			return paramMessage(number)(field)
+		}
+	}
+}
+
+func required(field Field) (bool, error) {
+	return !isEmpty(field.Value), nil
+}
+
+// compareRule returns a rule which checks the result of comparing the size
+// of field with the parameter
+func compareRule(accept func(cmp int) bool) RuleFunc {
+	return func(field Field) (bool, error) {
+		cmp, err := compareToParam(field)
+		if err != nil {
+			return false, err
+		}
+
+		return accept(cmp), nil
+	}
+}
+
+// equalRule returns a rule which checks equality of field with the
+// parameter. Strings and booleans are compared by their value and other
+// types by their size, similar to compareRule.
+func equalRule(equal bool) RuleFunc {
+	return func(field Field) (bool, error) {
+		switch field.Value.Kind() {
+		case reflect.String:
+			return (field.Value.String() == field.Param) == equal, nil
+		case reflect.Bool:
+			param, err := strconv.ParseBool(field.Param)
+			if err != nil {
+				return false, fmt.Errorf("invalid boolean %q", field.Param)
+			}
+
+			return (field.Value.Bool() == param) == equal, nil
+		}
+
+		cmp, err := compareToParam(field)
+		if err != nil {
+			return false, err
+		}
+
+		return (cmp == 0) == equal, nil
+	}
+}
+
+// compareToParam compares the size of field value with the parameter. Size
+// is the length of strings (in characters), slices, arrays and maps, and
+// the value of numbers and durations.
+func compareToParam(field Field) (int, error) {
+	v := field.Value
+
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		param, err := strconv.Atoi(field.Param)
+		if err != nil {
+			return 0, fmt.Errorf("invalid length %q", field.Param)
+		}
+
+		length := v.Len()
+		if v.Kind() == reflect.String {
+			length = utf8.RuneCountInString(v.String())
+		}
+
+		return compareInts(int64(length), int64(param)), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var param int64
+		var err error
+
+		if v.Type() == durationType {
+			var d time.Duration
+			d, err = time.ParseDuration(field.Param)
+			param = int64(d)
+		} else {
+			param, err = strconv.ParseInt(field.Param, 10, 64)
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", field.Param)
+		}
+
+		return compareInts(v.Int(), param), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		param, err := strconv.ParseUint(field.Param, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", field.Param)
+		}
+
+		return compareUints(v.Uint(), param), nil
+	case reflect.Float32, reflect.Float64:
+		param, err := strconv.ParseFloat(field.Param, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", field.Param)
+		}
+
+		return compareFloats(v.Float(), param), nil
+	default:
+		return 0, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+func oneOf(field Field) (bool, error) {
+	var value string
+
+	v := field.Value
+	switch v.Kind() {
+	case reflect.String:
+		value = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return false, fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	for _, option := range strings.Fields(field.Param) {
+		if option == value {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// patternRule returns a rule which checks string fields by given pattern
+func patternRule(pattern *regexp.Regexp) RuleFunc {
+	return func(field Field) (bool, error) {
+		if field.Value.Kind() != reflect.String {
+			return false, fmt.Errorf("unsupported type %s", field.Value.Type())
+		}
+
+		return pattern.MatchString(field.Value.String()), nil
+	}
+}
+
+func validURL(field Field) (bool, error) {
+	if field.Value.Kind() != reflect.String {
+		return false, fmt.Errorf("unsupported type %s", field.Value.Type())
+	}
+
+	u, err := url.ParseRequestURI(field.Value.String())
+	return err == nil && u.Scheme != "" && u.Host != "", nil
+}
+
+// fieldRule returns a cross-field rule which checks the result of comparing
+// field with the field in the parameter, which could be a dotted path of
+// nested fields of the parent struct. If the other field is a nil pointer,
+// only "nefield" passes.
+func fieldRule(accept func(cmp int) bool) RuleFunc {
+	return func(field Field) (bool, error) {
+		other, err := siblingField(field.Parent, field.Param)
+		if err != nil {
+			return false, err
+		}
+
+		if other = indirect(other); !other.IsValid() {
+			return !accept(0) && accept(1) && accept(-1), nil
+		}
+
+		if !field.Value.CanInterface() {
+			return false, errors.New("cannot compare an unexported field")
+		}
+
+		cmp, ok := compareValues(field.Value, other)
+		if !ok {
+			if !accept(0) && !accept(1) {
+				return false, fmt.Errorf("cannot compare %s with %s", field.Value.Type(), other.Type())
+			}
+
+			// Values which are not ordered could be checked for equality
+			equal := field.Value.Type() == other.Type() &&
+				reflect.DeepEqual(field.Value.Interface(), other.Interface())
+
+			return equal == accept(0), nil
+		}
+
+		return accept(cmp), nil
+	}
+}
+
+// siblingField returns the field in given dotted path of parent struct
+func siblingField(parent reflect.Value, path string) (reflect.Value, error) {
+	v := parent
+	for _, name := range strings.Split(path, ".") {
+		if v = indirect(v); !v.IsValid() {
+			return v, nil
+		}
+
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("field %q not found", path)
+		}
+
+		if v = v.FieldByName(name); !v.IsValid() {
+			return reflect.Value{}, fmt.Errorf("field %q not found", path)
+		}
+
+		// Values of unexported fields could not be compared
+		if !v.CanInterface() {
+			return reflect.Value{}, fmt.Errorf("field %q is unexported", path)
+		}
+	}
+
+	return v, nil
+}
+
+// compareValues compares numbers, strings and times, reporting false for
+// values which are not ordered
+func compareValues(a reflect.Value, b reflect.Value) (int, bool) {
+	switch {
+	case isInt(a) && isInt(b):
+		return compareInts(a.Int(), b.Int()), true
+	case isUint(a) && isUint(b):
+		return compareUints(a.Uint(), b.Uint()), true
+	case isNumber(a) && isNumber(b):
+		return compareFloats(toFloat(a), toFloat(b)), true
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return strings.Compare(a.String(), b.String()), true
+	case a.Type() == timeType && b.Type() == timeType:
+		at, bt := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case at.Before(bt):
+			return -1, true
+		case at.After(bt):
+			return 1, true
+		default:
+			return 0, true
+		}
+	default:
+		return 0, false
+	}
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isUint(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
+
+func isNumber(v reflect.Value) bool {
+	return isInt(v) || isUint(v) || v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case isInt(v):
+		return float64(v.Int())
+	case isUint(v):
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func compareInts(a int64, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareUints(a uint64, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloats(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
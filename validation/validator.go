@@ -0,0 +1,466 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Kamva/nautilus"
+)
+
+// Field is a value which is checked by a rule
+type Field struct {
+	// Value is the checked value. Pointers are dereferenced before rules
+	// are applied, except for the "required" rule.
+	Value reflect.Value
+
+	// Param is the parameter of the rule, e.g. "3" in "min=3"
+	Param string
+
+	// Parent is the struct containing the field, which is used by
+	// cross-field rules such as "eqfield"
+	Parent reflect.Value
+}
+
+// RuleFunc checks given field and reports whether it is valid. Returned
+// errors are for misconfigured rules, such as invalid parameters or
+// unsupported types, and are returned by Validate as is.
+type RuleFunc func(field Field) (bool, error)
+
+// Options contains options of Validator
+type Options struct {
+	// TagName is the name of the tag containing rules. Default is "validate".
+	TagName string
+
+	// NameFunc converts field names to the names used in error keys, for
+	// fields without a name in their `json` tag. Default is nautilus.ToCamel.
+	NameFunc func(name string) string
+}
+
+// rule is a registered rule with its error message generator
+type rule struct {
+	fn      RuleFunc
+	message func(field Field) string
+
+	// crossField is true for rules which their parameter is the path of
+	// another field, which is converted to its name in error keys before
+	// generating the message
+	crossField bool
+}
+
+// ruleCall is a rule with its parameter in a field tag
+type ruleCall struct {
+	name  string
+	param string
+}
+
+// fieldPlan is the parsed validation data of a struct field
+type fieldPlan struct {
+	index int
+
+	// name is the name of field in error keys, which is empty for embedded
+	// structs, since their fields are promoted to the parent
+	name string
+
+	rules []ruleCall
+}
+
+// Validator validates structs based on rules in their tags, such as
+// `validate:"required,min=3,max=64"`. Rules are separated by commas and
+// their parameters by "=". Rules are applied in order, except for these
+// keywords:
+//
+//   - omitempty skips the rest of rules if the value is empty
+//   - required fails if the value is empty (or a nil pointer) and skips
+//     the rest of rules when it fails
+//   - dive applies the rules after it on each element of slices, arrays
+//     and maps, e.g. `validate:"max=10,dive,required,email"`
+//
+// Nested structs (including elements of slices and maps) are validated
+// recursively and embedded structs are validated as a part of their
+// parent. Rules other than required are skipped for nil pointers.
+//
+// Validator is safe for concurrent use and parsed tags of each struct type
+// are cached.
+type Validator struct {
+	options Options
+
+	mutex sync.RWMutex
+	rules map[string]rule
+
+	plans sync.Map
+}
+
+var defaultValidator = New()
+
+// New returns a new Validator containing built-in rules
+func New(opts ...Options) *Validator {
+	v := &Validator{rules: make(map[string]rule, len(builtinRules))}
+
+	if len(opts) > 0 {
+		v.options = opts[0]
+	}
+
+	if v.options.TagName == "" {
+		v.options.TagName = "validate"
+	}
+
+	if v.options.NameFunc == nil {
+		v.options.NameFunc = nautilus.ToCamel
+	}
+
+	for name, r := range builtinRules {
+		v.rules[name] = r
+	}
+
+	return v
+}
+
+// Validate validates given struct using the default validator
+func Validate(s interface{}) error {
+	return defaultValidator.Validate(s)
+}
+
+// RegisterRule registers a custom rule in the default validator
+func RegisterRule(name string, fn RuleFunc, message string) {
+	defaultValidator.RegisterRule(name, fn, message)
+}
+
+// RegisterRule registers a custom rule with given name, replacing existing
+// rules with the same name. Message is the error message of failed rule,
+// in which "{param}" is replaced by the rule parameter.
+func (v *Validator) RegisterRule(name string, fn RuleFunc, message string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.rules[name] = rule{fn: fn, message: paramMessage(message)}
+}
+
+// Validate validates given struct (or pointer to struct) and returns Errors
+// if any of the fields is invalid. Other errors are returned for invalid
+// input, malformed tags and misconfigured rules.
+func (v *Validator) Validate(s interface{}) error {
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return errors.New("input must be a struct or a pointer to a struct")
+	}
+
+	errs := Errors{}
+	if err := v.validateStruct(rv, "", errs); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (v *Validator) validateStruct(rv reflect.Value, path string, errs Errors) error {
+	plans, err := v.plan(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		fieldPath := path
+		if plan.name != "" {
+			fieldPath = joinPath(path, plan.name)
+		}
+
+		if err := v.validateValue(rv.Field(plan.index), rv, fieldPath, plan.rules, errs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateValue(value reflect.Value, parent reflect.Value, path string, calls []ruleCall, errs Errors) error {
+	calls, elemCalls, dive := splitDive(calls)
+
+	for _, call := range calls {
+		if call.name == "omitempty" {
+			if isEmpty(value) {
+				return nil
+			}
+
+			continue
+		}
+
+		r, ok := v.rule(call.name)
+		if !ok {
+			return fmt.Errorf("field %s: unknown validation rule %q", path, call.name)
+		}
+
+		target := value
+		if call.name != "required" {
+			if target = indirect(value); !target.IsValid() {
+				return nil
+			}
+		}
+
+		field := Field{Value: target, Param: call.param, Parent: parent}
+		valid, err := r.fn(field)
+		if err != nil {
+			return fmt.Errorf("field %s: rule %s: %v", path, call.name, err)
+		}
+
+		if !valid {
+			messageField := field
+			if r.crossField {
+				messageField.Param = v.paramName(parent.Type(), call.param)
+			}
+
+			errs.add(path, FieldError{Rule: call.name, Param: call.param, Message: r.message(messageField)})
+
+			if call.name == "required" {
+				return nil
+			}
+		}
+	}
+
+	target := indirect(value)
+	if !target.IsValid() {
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		if dive {
+			return fmt.Errorf("field %s: cannot dive into %s", path, target.Type())
+		}
+
+		return v.validateStruct(target, path, errs)
+	case reflect.Slice, reflect.Array:
+		if !dive && !hasStructElem(target.Type()) {
+			return nil
+		}
+
+		for i := 0; i < target.Len(); i++ {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := v.validateValue(target.Index(i), parent, elemPath, elemCalls, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !dive && !hasStructElem(target.Type()) {
+			return nil
+		}
+
+		keys := target.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+
+		for _, key := range keys {
+			elemPath := fmt.Sprintf("%s[%v]", path, key)
+			if err := v.validateValue(target.MapIndex(key), parent, elemPath, elemCalls, errs); err != nil {
+				return err
+			}
+		}
+	default:
+		if dive {
+			return fmt.Errorf("field %s: cannot dive into %s", path, target.Type())
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) rule(name string) (rule, bool) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	r, ok := v.rules[name]
+	return r, ok
+}
+
+// plan returns the cached validation data of fields of given struct type
+func (v *Validator) plan(t reflect.Type) ([]fieldPlan, error) {
+	if cached, ok := v.plans.Load(t); ok {
+		return cached.([]fieldPlan), nil
+	}
+
+	sample := reflect.New(t).Interface()
+
+	fieldsData, err := nautilus.GetStructFieldsData(sample)
+	if err != nil {
+		return nil, err
+	}
+
+	var taggable nautilus.TagsGetter = nautilus.BaseTaggable{}
+	if t, ok := sample.(nautilus.TagsGetter); ok {
+		taggable = t
+	}
+
+	tags, err := taggable.GetTags(sample, v.options.TagName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", t, err)
+	}
+
+	jsonTags, err := taggable.GetTags(sample, "json")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", t, err)
+	}
+
+	var plans []fieldPlan
+	for i, fieldData := range fieldsData {
+		tag, hasRules := tags[fieldData.Name]
+		if hasRules && tag.Name == "-" {
+			continue
+		}
+
+		jsonTag := jsonTags[fieldData.Name]
+		embedded := fieldData.Anonymous && jsonTag.Name == "" && isStructType(fieldData.Type)
+		if !fieldData.Exported && !embedded {
+			continue
+		}
+
+		plan := fieldPlan{index: i}
+
+		switch {
+		case embedded:
+		case jsonTag.Name != "" && jsonTag.Name != "-":
+			plan.name = jsonTag.Name
+		default:
+			plan.name = v.options.NameFunc(fieldData.Name)
+		}
+
+		if hasRules {
+			plan.rules = ruleCalls(tag)
+		}
+
+		plans = append(plans, plan)
+	}
+
+	v.plans.Store(t, plans)
+
+	return plans, nil
+}
+
+// paramName converts the dotted field path in parameter of cross-field
+// rules into the names used in error keys, e.g. "Address.ZipCode" into
+// "address.zip_code" if the fields have such names in their json tags
+func (v *Validator) paramName(t reflect.Type, path string) string {
+	var names []string
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			return path
+		}
+
+		field, ok := t.FieldByName(name)
+		if !ok {
+			return path
+		}
+
+		jsonTag, err := nautilus.ParseTag(field.Tag.Get("json"))
+		if err == nil && jsonTag.Name != "" && jsonTag.Name != "-" {
+			names = append(names, jsonTag.Name)
+		} else {
+			names = append(names, v.options.NameFunc(field.Name))
+		}
+
+		t = field.Type
+	}
+
+	return strings.Join(names, ".")
+}
+
+// ruleCalls converts a parsed validation tag into rule calls. The tag name
+// is the first rule, which could have a parameter too.
+func ruleCalls(tag nautilus.Tag) []ruleCall {
+	var calls []ruleCall
+
+	if tag.Name != "" {
+		parts := strings.SplitN(tag.Name, "=", 2)
+		call := ruleCall{name: parts[0]}
+		if len(parts) == 2 {
+			call.param = parts[1]
+		}
+
+		calls = append(calls, call)
+	}
+
+	for _, option := range tag.Options {
+		calls = append(calls, ruleCall{name: option.Key, param: option.Value})
+	}
+
+	return calls
+}
+
+// splitDive splits rule calls into the rules of the value itself and the
+// rules of its elements, which are the ones after "dive"
+func splitDive(calls []ruleCall) ([]ruleCall, []ruleCall, bool) {
+	for i, call := range calls {
+		if call.name == "dive" {
+			return calls[:i], calls[i+1:], true
+		}
+	}
+
+	return calls, nil, false
+}
+
+// isEmpty checks whether given value is missing, the same as
+// nautilus.ApplyDefaults does: pointers and interfaces are missing only if
+// they are nil and other values if they are empty by nautilus.IsEmpty
+func isEmpty(v reflect.Value) bool {
+	switch {
+	case !v.IsValid():
+		return true
+	case v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface:
+		return v.IsNil()
+	case !v.CanInterface():
+		return v.IsZero()
+	default:
+		return nautilus.IsEmpty(v.Interface())
+	}
+}
+
+// indirect dereferences pointers and interfaces, returning an invalid value
+// if any of them is nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func isStructType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct
+}
+
+// hasStructElem checks whether elements of given slice, array or map type
+// are structs (or pointers to structs), which should be validated
+func hasStructElem(t reflect.Type) bool {
+	return isStructType(t.Elem())
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
@@ -0,0 +1,277 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kamva/nautilus"
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City    string `json:"city" validate:"required"`
+	ZipCode string `validate:"omitempty,numeric,len=5"`
+}
+
+type Audit struct {
+	CreatedBy string `json:"created_by" validate:"required"`
+}
+
+type item struct {
+	Name     string  `json:"name" validate:"required,max=8"`
+	Quantity int     `json:"qty" validate:"gt=0"`
+	Price    float64 `json:"price" validate:"gte=0"`
+}
+
+type signUpRequest struct {
+	Audit
+	Username        string            `json:"username" validate:"required,min=3,max=10,alphanum"`
+	Email           string            `json:"email" validate:"required,email"`
+	Website         string            `json:"website,omitempty" validate:"omitempty,url"`
+	Role            string            `json:"role" validate:"oneof=admin user guest"`
+	Password        string            `json:"password" validate:"required,min=8"`
+	PasswordConfirm string            `json:"password_confirm" validate:"eqfield=Password"`
+	Age             *int              `json:"age" validate:"omitempty,gte=18,lte=130"`
+	Nickname        *string           `validate:"required"`
+	Address         address           `json:"address"`
+	Billing         *address          `json:"billing"`
+	Tags            []string          `json:"tags" validate:"max=3,dive,required,min=2"`
+	Items           []item            `json:"items" validate:"required"`
+	Labels          map[string]string `json:"labels" validate:"dive,alpha"`
+	Timeout         time.Duration     `json:"timeout" validate:"omitempty,min=1s,max=1m"`
+	StartsAt        time.Time         `json:"starts_at"`
+	EndsAt          time.Time         `json:"ends_at" validate:"gtfield=StartsAt"`
+	Ignored         string            `json:"ignored" validate:"-"`
+	internal        string
+}
+
+func validRequest() signUpRequest {
+	nickname := "nick"
+	now := time.Now()
+
+	return signUpRequest{
+		Audit:           Audit{CreatedBy: "system"},
+		Username:        "john",
+		Email:           "john@example.com",
+		Website:         "https://example.com",
+		Role:            "user",
+		Password:        "secret123",
+		PasswordConfirm: "secret123",
+		Nickname:        &nickname,
+		Address:         address{City: "Tehran", ZipCode: "12345"},
+		Tags:            []string{"go", "web"},
+		Items:           []item{{Name: "book", Quantity: 1, Price: 10}},
+		Labels:          map[string]string{"env": "prod"},
+		StartsAt:        now,
+		EndsAt:          now.Add(time.Hour),
+		Ignored:         "x",
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	request := validRequest()
+
+	assert.Nil(t, Validate(request))
+	assert.Nil(t, Validate(&request))
+}
+
+func TestValidate_Invalid(t *testing.T) {
+	age := 10
+	request := validRequest()
+	request.Audit.CreatedBy = ""
+	request.Username = "jo"
+	request.Email = "john"
+	request.Website = "example.com"
+	request.Role = "root"
+	request.PasswordConfirm = "secret"
+	request.Age = &age
+	request.Nickname = nil
+	request.Address = address{ZipCode: "12a"}
+	request.Billing = &address{City: "Shiraz", ZipCode: "123456"}
+	request.Tags = []string{"go", "", "x", "web"}
+	request.Items = []item{{Name: "book"}, {Name: "long item name", Quantity: 1, Price: -1}}
+	request.Labels = map[string]string{"env": "prod1"}
+	request.Timeout = time.Hour
+	request.EndsAt = request.StartsAt
+
+	err := Validate(request)
+	errs, ok := err.(Errors)
+	assert.True(t, ok)
+
+	assert.Equal(t, map[string][]string{
+		"created_by":       {"is required"},
+		"username":         {"must be at least 3 characters long"},
+		"email":            {"must be a valid email address"},
+		"website":          {"must be a valid URL"},
+		"role":             {"must be one of admin, user, guest"},
+		"password_confirm": {"must be equal to password"},
+		"age":              {"must be greater than or equal to 18"},
+		"nickname":         {"is required"},
+		"address.city":     {"is required"},
+		"address.zipCode":  {"must be a number", "must be 5 characters long"},
+		"billing.zipCode":  {"must be 5 characters long"},
+		"tags":             {"must contain at most 3 items"},
+		"tags[1]":          {"is required"},
+		"tags[2]":          {"must be at least 2 characters long"},
+		"items[0].qty":     {"must be greater than 0"},
+		"items[1].name":    {"must be at most 8 characters long"},
+		"items[1].price":   {"must be greater than or equal to 0"},
+		"labels[env]":      {"must contain only letters"},
+		"timeout":          {"must be at most 1m"},
+		"ends_at":          {"must be greater than starts_at"},
+	}, errs.Messages())
+
+	assert.Equal(t, []FieldError{{Rule: "min", Param: "3", Message: "must be at least 3 characters long"}}, errs["username"])
+	assert.True(t, strings.HasPrefix(err.Error(), "address.city: is required; address.zipCode: must be a number; "))
+}
+
+// version is zero if its major version is zero
+type version struct {
+	Major int
+	Minor int
+}
+
+func (v version) IsZero() bool {
+	return v.Major == 0
+}
+
+func TestValidate_Required(t *testing.T) {
+	type request struct {
+		Items   []int          `validate:"required"`
+		Map     map[string]int `validate:"required"`
+		Flag    *bool          `validate:"required"`
+		Any     interface{}    `validate:"required"`
+		Version version        `validate:"required"`
+		Fix     version        `validate:"omitempty,required"`
+	}
+
+	flag := false
+	assert.Nil(t, Validate(request{Items: []int{0}, Map: map[string]int{"a": 0}, Flag: &flag, Any: 0, Version: version{Major: 1}}))
+
+	err := Validate(request{Items: []int{}, Version: version{Minor: 1}, Fix: version{Minor: 1}})
+	assert.Equal(t, map[string][]string{
+		"items":   {"is required"},
+		"map":     {"is required"},
+		"flag":    {"is required"},
+		"any":     {"is required"},
+		"version": {"is required"},
+	}, err.(Errors).Messages())
+}
+
+func TestValidate_CrossField(t *testing.T) {
+	type window struct {
+		Min   int `validate:"ltefield=Max"`
+		Max   int `validate:"gtefield=Min,nefield=Limit.Value"`
+		Limit *struct {
+			Value int
+		}
+		Name  string `validate:"nefield=Other"`
+		Other string
+	}
+
+	assert.Nil(t, Validate(window{Min: 1, Max: 2, Name: "a", Other: "b"}))
+
+	err := Validate(window{Min: 3, Max: 2, Limit: &struct{ Value int }{Value: 2}, Name: "a", Other: "a"})
+	assert.Equal(t, map[string][]string{
+		"min":  {"must be less than or equal to max"},
+		"max":  {"must be greater than or equal to min", "must not be equal to limit.value"},
+		"name": {"must not be equal to other"},
+	}, err.(Errors).Messages())
+
+	type invalid struct {
+		Name string `validate:"eqfield=Missing"`
+	}
+
+	err = Validate(invalid{})
+	assert.EqualError(t, err, `field name: rule eqfield: field "Missing" not found`)
+
+	type unexported struct {
+		Name   string `validate:"eqfield=secret"`
+		secret string
+	}
+
+	err = Validate(unexported{secret: "a"})
+	assert.EqualError(t, err, `field name: rule eqfield: field "secret" is unexported`)
+}
+
+func TestValidator_Options(t *testing.T) {
+	type request struct {
+		FirstName    string `json:"-" check:"required"`
+		LastName     string `check:"required"`
+		MaxRetries   int
+		RetryTimeout int `check:"ltfield=MaxRetries"`
+	}
+
+	validator := New(Options{TagName: "check", NameFunc: nautilus.ToSnake})
+	err := validator.Validate(&request{MaxRetries: 1, RetryTimeout: 2})
+
+	assert.Equal(t, map[string][]string{
+		"first_name":    {"is required"},
+		"last_name":     {"is required"},
+		"retry_timeout": {"must be less than max_retries"},
+	}, err.(Errors).Messages())
+}
+
+func TestValidator_RegisterRule(t *testing.T) {
+	type request struct {
+		Code  string `validate:"prefix=INV-"`
+		Other string `validate:"unknown"`
+	}
+
+	validator := New()
+	validator.RegisterRule("prefix", func(field Field) (bool, error) {
+		return strings.HasPrefix(field.Value.String(), field.Param), nil
+	}, "must start with {param}")
+
+	err := validator.Validate(request{Code: "ABC"})
+	assert.EqualError(t, err, `field other: unknown validation rule "unknown"`)
+
+	validator.RegisterRule("unknown", func(field Field) (bool, error) {
+		return false, errors.New("broken rule")
+	}, "")
+
+	err = validator.Validate(request{Code: "ABC"})
+	assert.EqualError(t, err, "field other: rule unknown: broken rule")
+
+	validator.RegisterRule("unknown", func(field Field) (bool, error) {
+		return true, nil
+	}, "")
+
+	err = validator.Validate(request{Code: "ABC"})
+	assert.Equal(t, Errors{"code": {{Rule: "prefix", Param: "INV-", Message: "must start with INV-"}}}, err)
+	assert.Nil(t, validator.Validate(request{Code: "INV-1"}))
+
+	// Custom rules of a validator are not registered in the default one
+	assert.NotNil(t, Validate(request{Code: "INV-1"}))
+}
+
+func TestValidate_Errors(t *testing.T) {
+	assert.EqualError(t, Validate(42), "input must be a struct or a pointer to a struct")
+	assert.EqualError(t, Validate((*signUpRequest)(nil)), "input must be a struct or a pointer to a struct")
+
+	type badParam struct {
+		Name string `validate:"min=abc"`
+	}
+
+	assert.EqualError(t, Validate(badParam{}), `field name: rule min: invalid length "abc"`)
+
+	type badType struct {
+		Flag bool `validate:"email"`
+	}
+
+	assert.EqualError(t, Validate(badType{}), "field flag: rule email: unsupported type bool")
+
+	type badDive struct {
+		Name string `validate:"dive,required"`
+	}
+
+	assert.EqualError(t, Validate(badDive{Name: "a"}), "field name: cannot dive into string")
+
+	type badTag struct {
+		Name string `validate:"required,,min=1"`
+	}
+
+	assert.NotNil(t, Validate(badTag{}))
+}
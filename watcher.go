@@ -0,0 +1,446 @@
+package nautilus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported configuration file formats of ConfigWatcher
+const (
+	FormatEnv  = "env"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// ConfigChange is passed to ConfigWatcher subscribers after a successful
+// reload, containing both versions of the config and the changed fields
+// as reported by DiffStructs
+type ConfigChange struct {
+	Old     interface{}
+	New     interface{}
+	Changes []Change
+}
+
+// WatcherOptions contains options of ConfigWatcher
+type WatcherOptions struct {
+	// Format of the config file which is one of FormatEnv, FormatJSON or
+	// FormatYAML. If it is empty, format is detected by file extension and
+	// files with unknown extensions (e.g. ".env") are treated as env files.
+	Format string
+
+	// Validate is called with each newly loaded config (a pointer to the
+	// config struct) and the config is rejected if it returns an error.
+	// Configs implementing `Validate() error` are also validated by it.
+	Validate func(config interface{}) error
+
+	// PollInterval is the interval of checking the file for changes when
+	// file system notifications are not available. Default is 2 seconds.
+	PollInterval time.Duration
+
+	// ForcePolling disables file system notifications and uses polling
+	ForcePolling bool
+
+	// ErrorHandler is called with errors of rejected reloads. By default,
+	// errors are logged using standard logger.
+	ErrorHandler func(err error)
+}
+
+// ConfigWatcher loads a config file into a typed struct and reloads it
+// whenever the file changes. Loaded configs are swapped atomically, so
+// Config is safe to be called concurrently, and subscribers are notified
+// about changed fields. Invalid configs are rejected and the current
+// config is kept.
+type ConfigWatcher struct {
+	path    string
+	typ     reflect.Type
+	options WatcherOptions
+
+	current atomic.Value
+
+	reloadMutex sync.Mutex
+	subMutex    sync.Mutex
+	subscribers map[int]func(ConfigChange)
+	nextID      int
+
+	// queue contains the changes which subscribers are not notified about
+	// yet, in the order of reloads. draining is set while a goroutine is
+	// notifying subscribers about the queued changes.
+	queueMutex sync.Mutex
+	queue      []ConfigChange
+	draining   bool
+
+	notifier  *fsnotify.Watcher
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// WatchConfig loads the config file in given path into config, which must
+// be a pointer to a struct, and starts watching the file for changes.
+// Each reload decodes the file into a new zero valued struct of the same
+// type. The current config could be retrieved by Config method.
+func WatchConfig(path string, config interface{}, opts ...WatcherOptions) (*ConfigWatcher, error) {
+	rv := reflect.ValueOf(config)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config must be a non-nil pointer to a struct")
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &ConfigWatcher{
+		path:        absPath,
+		typ:         rv.Elem().Type(),
+		subscribers: make(map[int]func(ConfigChange)),
+		done:        make(chan struct{}),
+	}
+
+	if len(opts) > 0 {
+		w.options = opts[0]
+	}
+
+	if w.options.PollInterval <= 0 {
+		w.options.PollInterval = 2 * time.Second
+	}
+
+	if w.options.ErrorHandler == nil {
+		w.options.ErrorHandler = func(err error) {
+			log.Printf("nautilus: config reload rejected: %v", err)
+		}
+	}
+
+	// State is read before loading, so changes made during loading are
+	// not missed by polling
+	state := fileState(absPath)
+
+	loaded, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+
+	rv.Elem().Set(reflect.ValueOf(loaded).Elem())
+	w.current.Store(loaded)
+
+	w.wg.Add(1)
+	if w.options.ForcePolling || w.startNotifier() != nil {
+		go w.poll(state)
+	} else {
+		go w.watch()
+	}
+
+	return w, nil
+}
+
+// Config returns the current config, which is a pointer to the config
+// struct. The returned value must be treated as read-only.
+func (w *ConfigWatcher) Config() interface{} {
+	return w.current.Load()
+}
+
+// Subscribe registers given function to be called after each successful
+// reload that changed the config. It returns a function for removing the
+// subscription.
+func (w *ConfigWatcher) Subscribe(fn func(ConfigChange)) (unsubscribe func()) {
+	w.subMutex.Lock()
+	defer w.subMutex.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = fn
+
+	return func() {
+		w.subMutex.Lock()
+		defer w.subMutex.Unlock()
+
+		delete(w.subscribers, id)
+	}
+}
+
+// Reload reloads the config file immediately. The new config is swapped
+// and subscribers are notified only if it is valid and differs from the
+// current config. Subscribers are notified in the order of reloads and
+// after the reload is finished, so they could call Reload or Close
+// themselves. If subscribers are being notified about another change
+// meanwhile (e.g. Reload is called by a subscriber), the change is queued
+// and Reload returns without waiting for its notification.
+func (w *ConfigWatcher) Reload() error {
+	changed, err := w.swap()
+	if err != nil || !changed {
+		return err
+	}
+
+	w.drain()
+
+	return nil
+}
+
+// swap loads the config file and swaps the current config if it is
+// changed, queueing the change. Changes are queued before releasing the
+// reload lock, so they are queued in the order of reloads.
+func (w *ConfigWatcher) swap() (bool, error) {
+	w.reloadMutex.Lock()
+	defer w.reloadMutex.Unlock()
+
+	loaded, err := w.load()
+	if err != nil {
+		return false, err
+	}
+
+	old := w.current.Load()
+	changes, err := DiffStructs(old, loaded)
+	if err != nil {
+		return false, err
+	}
+
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	w.current.Store(loaded)
+
+	w.queueMutex.Lock()
+	w.queue = append(w.queue, ConfigChange{Old: old, New: loaded, Changes: changes})
+	w.queueMutex.Unlock()
+
+	return true, nil
+}
+
+// drain notifies subscribers about the queued changes in order, unless
+// another goroutine is already doing so, in which case that goroutine
+// notifies them about the changes queued meanwhile too
+func (w *ConfigWatcher) drain() {
+	w.queueMutex.Lock()
+	defer w.queueMutex.Unlock()
+
+	if w.draining {
+		return
+	}
+
+	w.draining = true
+	for len(w.queue) > 0 && !w.closed() {
+		change := w.queue[0]
+		w.queue = w.queue[1:]
+
+		w.queueMutex.Unlock()
+		w.notify(change)
+		w.queueMutex.Lock()
+	}
+
+	w.queue = nil
+	w.draining = false
+}
+
+// Close stops watching the config file and waits for the watching
+// goroutine to stop. Subscribers are not notified after closing, except
+// for the ones which are already being called, so it could be called by
+// subscribers too.
+func (w *ConfigWatcher) Close() error {
+	var err error
+
+	w.closeOnce.Do(func() {
+		close(w.done)
+
+		if w.notifier != nil {
+			err = w.notifier.Close()
+		}
+
+		w.wg.Wait()
+	})
+
+	return err
+}
+
+func (w *ConfigWatcher) closed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *ConfigWatcher) notify(change ConfigChange) {
+	w.subMutex.Lock()
+	subscribers := make([]func(ConfigChange), 0, len(w.subscribers))
+	for id := 0; id < w.nextID; id++ {
+		if fn, ok := w.subscribers[id]; ok {
+			subscribers = append(subscribers, fn)
+		}
+	}
+	w.subMutex.Unlock()
+
+	for _, fn := range subscribers {
+		if w.closed() {
+			return
+		}
+
+		fn(change)
+	}
+}
+
+// load reads, decodes and validates the config file into a new struct
+func (w *ConfigWatcher) load() (interface{}, error) {
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := reflect.New(w.typ).Interface()
+
+	switch w.format() {
+	case FormatJSON:
+		err = json.Unmarshal(data, config)
+	case FormatYAML:
+		err = yaml.Unmarshal(data, config)
+	case FormatEnv:
+		var source MapSource
+		if source, err = ParseDotEnv(bytes.NewReader(data)); err == nil {
+			err = LoadEnvFrom(LayeredSource{EnvSource(), source}, config)
+		}
+	default:
+		err = fmt.Errorf("unsupported config format %q", w.options.Format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", w.path, err)
+	}
+
+	if validator, ok := config.(interface{ Validate() error }); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %v", w.path, err)
+		}
+	}
+
+	if w.options.Validate != nil {
+		if err := w.options.Validate(config); err != nil {
+			return nil, fmt.Errorf("%s: %v", w.path, err)
+		}
+	}
+
+	return config, nil
+}
+
+func (w *ConfigWatcher) format() string {
+	if w.options.Format != "" {
+		return w.options.Format
+	}
+
+	switch strings.ToLower(filepath.Ext(w.path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatEnv
+	}
+}
+
+// startNotifier starts watching the directory of config file, so atomic
+// replacements of the file (e.g. by editors or Kubernetes volumes) are
+// also detected
+func (w *ConfigWatcher) startNotifier() error {
+	notifier, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	if err := notifier.Add(filepath.Dir(w.path)); err != nil {
+		_ = notifier.Close()
+		return err
+	}
+
+	w.notifier = notifier
+	return nil
+}
+
+func (w *ConfigWatcher) watch() {
+	defer w.wg.Done()
+
+	// Writes usually produce several events, so reloads are debounced
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.notifier.Events:
+			if !ok {
+				return
+			}
+
+			if filepath.Clean(event.Name) == w.path || event.Op&fsnotify.Create == fsnotify.Create {
+				debounce = time.After(50 * time.Millisecond)
+			}
+		case err, ok := <-w.notifier.Errors:
+			if !ok {
+				return
+			}
+
+			w.options.ErrorHandler(err)
+		case <-debounce:
+			debounce = nil
+			w.reload()
+		}
+	}
+}
+
+func (w *ConfigWatcher) poll(lastState string) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			state := fileState(w.path)
+			if state != lastState {
+				lastState = state
+				w.reload()
+			}
+		}
+	}
+}
+
+// reload is called by the watching goroutine. Subscribers are notified by
+// another goroutine, so the watching goroutine never runs subscribers and
+// Close could always wait for it.
+func (w *ConfigWatcher) reload() {
+	changed, err := w.swap()
+	if err != nil {
+		w.options.ErrorHandler(err)
+		return
+	}
+
+	if changed {
+		go w.drain()
+	}
+}
+
+// fileState returns modification time and size of the file as a string,
+// which changes whenever the file is modified
+func fileState(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+}
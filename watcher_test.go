@@ -0,0 +1,286 @@
+package nautilus
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type watchedLimits struct {
+	Rate  int `json:"rate" yaml:"rate" env:"LIMIT_RATE"`
+	Burst int `json:"burst" yaml:"burst" env:"LIMIT_BURST"`
+}
+
+type watchedConfig struct {
+	Feature bool          `json:"feature" yaml:"feature" env:"FEATURE"`
+	Limits  watchedLimits `json:"limits" yaml:"limits"`
+}
+
+func (c *watchedConfig) Validate() error {
+	if c.Limits.Rate < 0 {
+		return errors.New("rate must not be negative")
+	}
+
+	return nil
+}
+
+func writeConfig(t *testing.T, path string, content string) {
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0600))
+}
+
+func waitForChange(t *testing.T, changes <-chan ConfigChange) ConfigChange {
+	select {
+	case change := <-changes:
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change was not received")
+		return ConfigChange{}
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	formats := map[string]map[string]string{
+		"config.json": {
+			"initial": `{"feature": false, "limits": {"rate": 10, "burst": 20}}`,
+			"changed": `{"feature": true, "limits": {"rate": 15, "burst": 20}}`,
+			"invalid": `{"feature": true, "limits": {"rate": -1, "burst": 20}}`,
+		},
+		"config.yaml": {
+			"initial": "feature: false\nlimits:\n  rate: 10\n  burst: 20\n",
+			"changed": "feature: true\nlimits:\n  rate: 15\n  burst: 20\n",
+			"invalid": "feature: [\n",
+		},
+		".env": {
+			"initial": "FEATURE=false\nLIMIT_RATE=10\nLIMIT_BURST=20\n",
+			"changed": "FEATURE=true\nLIMIT_RATE=15\nLIMIT_BURST=20\n",
+			"invalid": "FEATURE=maybe\nLIMIT_RATE=15\nLIMIT_BURST=20\n",
+		},
+	}
+
+	for name, contents := range formats {
+		for mode, polling := range map[string]bool{"polling": true, "notification": false} {
+			t.Run(name+" "+mode, func(t *testing.T) {
+				dir, err := ioutil.TempDir("", "nautilus-watcher")
+				assert.Nil(t, err)
+				defer os.RemoveAll(dir)
+
+				path := filepath.Join(dir, name)
+				writeConfig(t, path, contents["initial"])
+
+				rejected := make(chan error, 10)
+				var config watchedConfig
+				w, err := WatchConfig(path, &config, WatcherOptions{
+					ForcePolling: polling,
+					PollInterval: 10 * time.Millisecond,
+					ErrorHandler: func(err error) { rejected <- err },
+				})
+				assert.Nil(t, err)
+				defer w.Close()
+
+				assert.Equal(t, watchedConfig{Limits: watchedLimits{Rate: 10, Burst: 20}}, config)
+
+				changes := make(chan ConfigChange, 10)
+				w.Subscribe(func(change ConfigChange) { changes <- change })
+
+				writeConfig(t, path, contents["changed"])
+				change := waitForChange(t, changes)
+
+				assert.Equal(t, []Change{
+					{Path: "Feature", Old: false, New: true},
+					{Path: "Limits.Rate", Old: 10, New: 15},
+				}, change.Changes)
+				assert.Equal(t, &watchedConfig{Feature: true, Limits: watchedLimits{Rate: 15, Burst: 20}}, w.Config())
+
+				writeConfig(t, path, contents["invalid"])
+				select {
+				case err := <-rejected:
+					assert.NotNil(t, err)
+				case <-time.After(5 * time.Second):
+					t.Fatal("invalid config was not rejected")
+				}
+
+				assert.Equal(t, &watchedConfig{Feature: true, Limits: watchedLimits{Rate: 15, Burst: 20}}, w.Config())
+			})
+		}
+	}
+}
+
+func TestConfigWatcher_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nautilus-watcher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	writeConfig(t, path, `{"limits": {"rate": 1}}`)
+
+	var config watchedConfig
+	w, err := WatchConfig(path, &config, WatcherOptions{
+		ForcePolling: true,
+		PollInterval: time.Hour,
+		Validate: func(config interface{}) error {
+			if config.(*watchedConfig).Limits.Burst > 100 {
+				return errors.New("burst is too large")
+			}
+
+			return nil
+		},
+	})
+	assert.Nil(t, err)
+	defer w.Close()
+
+	calls := 0
+	unsubscribe := w.Subscribe(func(ConfigChange) { calls++ })
+
+	assert.Nil(t, w.Reload())
+	assert.Equal(t, 0, calls)
+
+	writeConfig(t, path, `{"limits": {"rate": 2}}`)
+	assert.Nil(t, w.Reload())
+	assert.Equal(t, 1, calls)
+
+	writeConfig(t, path, `{"limits": {"rate": 2, "burst": 101}}`)
+	assert.EqualError(t, w.Reload(), path+": burst is too large")
+	assert.Equal(t, 2, w.Config().(*watchedConfig).Limits.Rate)
+
+	unsubscribe()
+	writeConfig(t, path, `{"limits": {"rate": 3}}`)
+	assert.Nil(t, w.Reload())
+	assert.Equal(t, 1, calls)
+
+	_, err = WatchConfig(filepath.Join(dir, "missing.json"), &config)
+	assert.NotNil(t, err)
+
+	_, err = WatchConfig(path, config)
+	assert.NotNil(t, err)
+}
+
+func TestConfigWatcher_ReentrantSubscribers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nautilus-watcher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	writeConfig(t, path, `{"limits": {"rate": 1}}`)
+
+	var config watchedConfig
+	w, err := WatchConfig(path, &config, WatcherOptions{ForcePolling: true, PollInterval: 10 * time.Millisecond})
+	assert.Nil(t, err)
+
+	closed := make(chan error, 1)
+	w.Subscribe(func(change ConfigChange) {
+		// Both calls deadlock if subscribers are notified under the lock
+		assert.Nil(t, w.Reload())
+		closed <- w.Close()
+	})
+
+	writeConfig(t, path, `{"limits": {"rate": 2}}`)
+
+	select {
+	case err := <-closed:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscriber could not reload and close the watcher")
+	}
+
+	assert.Equal(t, 2, w.Config().(*watchedConfig).Limits.Rate)
+}
+
+func TestConfigWatcher_OrderedNotifications(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nautilus-watcher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	writeConfig(t, path, `{"limits": {"rate": 0}}`)
+
+	var config watchedConfig
+	w, err := WatchConfig(path, &config, WatcherOptions{ForcePolling: true, PollInterval: time.Millisecond})
+	assert.Nil(t, err)
+	defer w.Close()
+
+	var mutex sync.Mutex
+	var changes []ConfigChange
+	w.Subscribe(func(change ConfigChange) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		changes = append(changes, change)
+	})
+
+	for i := 1; i <= 20; i++ {
+		writeConfig(t, path, `{"limits": {"rate": `+strconv.Itoa(i)+`}}`)
+
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Nil(t, w.Reload())
+			}()
+		}
+		wg.Wait()
+	}
+
+	assert.Nil(t, w.Close())
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Every change must start from the config of the previous one
+	assert.NotEmpty(t, changes)
+	for i := 1; i < len(changes); i++ {
+		assert.True(t, changes[i-1].New == changes[i].Old, "change %d is notified out of order", i)
+	}
+}
+
+func TestConfigWatcher_CloseWhileNotifying(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nautilus-watcher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	writeConfig(t, path, `{"limits": {"rate": 1}}`)
+
+	var config watchedConfig
+	w, err := WatchConfig(path, &config, WatcherOptions{ForcePolling: true, PollInterval: 10 * time.Millisecond})
+	assert.Nil(t, err)
+
+	var calls int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	w.Subscribe(func(change ConfigChange) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(entered)
+			<-release
+		}
+	})
+
+	writeConfig(t, path, `{"limits": {"rate": 2}}`)
+
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change was not received")
+	}
+
+	// The change is queued, since the subscriber is still being notified
+	writeConfig(t, path, `{"limits": {"rate": 3}}`)
+	assert.Nil(t, w.Reload())
+
+	// Close waits for the watching goroutine even though a subscriber is
+	// running, and queued changes are not notified after closing
+	assert.Nil(t, w.Close())
+	close(release)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, 3, w.Config().(*watchedConfig).Limits.Rate)
+}